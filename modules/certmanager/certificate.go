@@ -19,6 +19,8 @@ package certmanager
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"slices"
 	"sort"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/openstack-k8s-operators/lib-common/modules/common/net"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/secret"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 
 	"golang.org/x/exp/maps"
@@ -62,6 +65,8 @@ type CertificateRequest struct {
 	Labels      map[string]string
 	Usages      []certmgrv1.KeyUsage
 	Subject     *certmgrv1.X509Subject
+	// ClientAuth - adds the clientAuth usage to the Usages, e.g. for mutual TLS
+	ClientAuth bool
 }
 
 // NewCertificate returns an initialized Certificate.
@@ -165,6 +170,7 @@ func (c *Certificate) Delete(
 }
 
 // EnsureCert - creates a certificate, ensures the secret has the required key/cert and return the secret
+// A requeue is requested until the cert in the secret covers the requested hostnames and IPs.
 func EnsureCert(
 	ctx context.Context,
 	helper *helper.Helper,
@@ -195,6 +201,9 @@ func EnsureCert(
 			certmgrv1.UsageServerAuth,
 		}
 	}
+	if request.ClientAuth && !slices.Contains(request.Usages, certmgrv1.UsageClientAuth) {
+		request.Usages = append(slices.Clone(request.Usages), certmgrv1.UsageClientAuth)
+	}
 
 	certSecretName := "cert-" + request.CertName
 	certSpec := certmgrv1.CertificateSpec{
@@ -266,9 +275,47 @@ func EnsureCert(
 		return nil, ctrl.Result{}, err
 	}
 
+	// if the existing cert does not cover the requested SANs, e.g. because
+	// hostnames got added to the request, cert-manager re-issues it because of
+	// the spec update. Until the secret got updated with the new cert it is
+	// not ready to be used.
+	dnsNames, ipAddresses, err := tls.ParseCertSANs(certSecret.Data[tls.CertKey])
+	if err != nil {
+		helper.GetLogger().Info(fmt.Sprintf("Unable to get the SANs of certificate %s, reconcile in %s: %s", certReq.Name, cert.timeout, err))
+		return nil, ctrl.Result{RequeueAfter: cert.timeout}, nil
+	} else if !sansCovered(certSpec.DNSNames, certSpec.IPAddresses, dnsNames, ipAddresses) {
+		helper.GetLogger().Info(fmt.Sprintf("Certificate %s does not cover the requested SANs yet, reconcile in %s", certReq.Name, cert.timeout))
+		return nil, ctrl.Result{RequeueAfter: cert.timeout}, nil
+	}
+
 	return certSecret, ctrl.Result{}, nil
 }
 
+// sansCovered - returns true if all requested DNS names and IP addresses are
+// part of the SANs of the issued certificate
+func sansCovered(requestedDNSNames []string, requestedIPs []string, dnsNames []string, ipAddresses []string) bool {
+	for _, name := range requestedDNSNames {
+		if !slices.Contains(dnsNames, name) {
+			return false
+		}
+	}
+
+	issuedIPs := []netip.Addr{}
+	for _, ip := range ipAddresses {
+		if addr, err := netip.ParseAddr(ip); err == nil {
+			issuedIPs = append(issuedIPs, addr)
+		}
+	}
+	for _, ip := range requestedIPs {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil || !slices.Contains(issuedIPs, addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // EnsureCertForServicesWithSelector - creates certificate for k8s services identified
 // by a label selector
 func EnsureCertForServicesWithSelector(
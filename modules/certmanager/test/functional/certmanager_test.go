@@ -23,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
 
 	certmgrv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	certmgrmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
@@ -305,8 +306,12 @@ var _ = Describe("certmanager module", func() {
 			},
 		})
 		// simulate underlying cert secrets exist
-		th.CreateCertSecret(types.NamespacedName{Name: "cert-svc1-svc", Namespace: names.Namespace})
-		th.CreateCertSecret(types.NamespacedName{Name: "cert-svc2-svc", Namespace: names.Namespace})
+		th.CreateCertSecretWithSANs(
+			types.NamespacedName{Name: "cert-svc1-svc", Namespace: names.Namespace},
+			[]string{fmt.Sprintf("svc1.%s.svc", names.Namespace)})
+		th.CreateCertSecretWithSANs(
+			types.NamespacedName{Name: "cert-svc2-svc", Namespace: names.Namespace},
+			[]string{fmt.Sprintf("svc2.%s.svc", names.Namespace)})
 
 		certs, _, err := certmanager.EnsureCertForServicesWithSelector(
 			th.Ctx, h, names.Namespace, map[string]string{"foo": ""}, names.CAName.Name, nil)
@@ -364,7 +369,9 @@ var _ = Describe("certmanager module", func() {
 			},
 		})
 		// simulate underlying cert secret exist
-		th.CreateCertSecret(types.NamespacedName{Name: "cert-svc2-svc", Namespace: names.Namespace})
+		th.CreateCertSecretWithSANs(
+			types.NamespacedName{Name: "cert-svc2-svc", Namespace: names.Namespace},
+			[]string{fmt.Sprintf("svc2.%s.svc", names.Namespace)})
 
 		cert, _, err := certmanager.EnsureCertForServiceWithSelector(
 			th.Ctx, h, names.Namespace, map[string]string{"foo": "2"}, names.CAName.Name, nil)
@@ -373,6 +380,106 @@ var _ = Describe("certmanager module", func() {
 
 	})
 
+	It("re-issues a certificate when hostnames get added", func() {
+		i := certmanager.NewIssuer(
+			certmanager.CAIssuer(
+				"ca",
+				names.Namespace,
+				map[string]string{"f": "l"},
+				map[string]string{},
+				"secret",
+			),
+			timeout,
+		)
+
+		_, err := i.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// simulate the issued cert secret, the cert has the test-svc SAN
+		th.CreateCertSecret(types.NamespacedName{Name: "cert-test-svc", Namespace: names.Namespace})
+		certName := types.NamespacedName{Name: "test-svc", Namespace: names.Namespace}
+
+		request := certmanager.CertificateRequest{
+			IssuerName: names.CAName.Name,
+			CertName:   certName.Name,
+			Hostnames:  []string{"test-svc"},
+		}
+		certSecret, ctrlResult, err := certmanager.EnsureCert(ctx, h, request, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ctrlResult).To(Equal(ctrl.Result{}))
+		Expect(certSecret).NotTo(BeNil())
+
+		cert := th.GetCert(certName)
+		Expect(cert.Spec.DNSNames).To(ConsistOf("test-svc"))
+		Expect(cert.Status.Conditions).To(BeEmpty())
+
+		request.Hostnames = []string{"test-svc", "extra-svc"}
+		certSecret, ctrlResult, err = certmanager.EnsureCert(ctx, h, request, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ctrlResult.RequeueAfter).NotTo(BeZero())
+		Expect(certSecret).To(BeNil())
+
+		// the spec update makes cert-manager re-issue the cert, until then
+		// the cert is not ready
+		cert = th.GetCert(certName)
+		Expect(cert.Spec.DNSNames).To(ConsistOf("test-svc", "extra-svc"))
+
+		// the re-issued cert covers the new hostname
+		th.UpdateCertSecretSANs(
+			types.NamespacedName{Name: "cert-test-svc", Namespace: names.Namespace},
+			[]string{"test-svc", "extra-svc"})
+		certSecret, ctrlResult, err = certmanager.EnsureCert(ctx, h, request, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ctrlResult).To(Equal(ctrl.Result{}))
+		Expect(certSecret).NotTo(BeNil())
+
+		// a cert which can not be parsed is not ready
+		certSecret.Data["tls.crt"] = []byte("invalid")
+		Expect(k8sClient.Update(ctx, certSecret)).To(Succeed())
+		certSecret, ctrlResult, err = certmanager.EnsureCert(ctx, h, request, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ctrlResult.RequeueAfter).NotTo(BeZero())
+		Expect(certSecret).To(BeNil())
+	})
+
+	It("creates a certificate with client auth usage", func() {
+		i := certmanager.NewIssuer(
+			certmanager.CAIssuer(
+				"ca",
+				names.Namespace,
+				map[string]string{"f": "l"},
+				map[string]string{},
+				"secret",
+			),
+			timeout,
+		)
+
+		_, err := i.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		th.CreateCertSecret(types.NamespacedName{Name: "cert-test-svc", Namespace: names.Namespace})
+		certName := types.NamespacedName{Name: "test-svc", Namespace: names.Namespace}
+
+		request := certmanager.CertificateRequest{
+			IssuerName: names.CAName.Name,
+			CertName:   certName.Name,
+			Hostnames:  []string{"test-svc"},
+			ClientAuth: true,
+		}
+		certSecret, ctrlResult, err := certmanager.EnsureCert(ctx, h, request, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ctrlResult).To(Equal(ctrl.Result{}))
+		Expect(certSecret).NotTo(BeNil())
+
+		cert := th.GetCert(certName)
+		Expect(cert.Spec.Usages).To(ConsistOf(
+			certmgrv1.UsageKeyEncipherment,
+			certmgrv1.UsageDigitalSignature,
+			certmgrv1.UsageServerAuth,
+			certmgrv1.UsageClientAuth,
+		))
+	})
+
 	It("fails to create a certificate for a specific k8s service if the label selector returns not a single service", func() {
 		i := certmanager.NewIssuer(
 			certmanager.CAIssuer(
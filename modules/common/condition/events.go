@@ -0,0 +1,58 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// RecordConditionEvent - emits an Event for the condition c on obj. A condition
+// with Status=False results in a Warning event, any other condition in a Normal
+// event. The event reason is the condition reason, or the condition type if the
+// condition has no reason.
+func RecordConditionEvent(recorder record.EventRecorder, obj runtime.Object, c *Condition) {
+	if recorder == nil || c == nil {
+		return
+	}
+
+	eventType := corev1.EventTypeNormal
+	if c.Status == corev1.ConditionFalse {
+		eventType = corev1.EventTypeWarning
+	}
+
+	reason := string(c.Reason)
+	if reason == "" {
+		reason = string(c.Type)
+	}
+
+	recorder.Eventf(obj, eventType, reason, "%s %s: %s", c.Type, c.Status, c.Message)
+}
+
+// RecordTransitions - emits an Event for each condition in newConditions which
+// does not exist in oldConditions or which state is different from the one in
+// oldConditions.
+func RecordTransitions(recorder record.EventRecorder, obj runtime.Object, oldConditions Conditions, newConditions Conditions) {
+	for i := range newConditions {
+		c := &newConditions[i]
+		if oldC := oldConditions.Get(c.Type); oldC != nil && HasSameState(oldC, c) {
+			continue
+		}
+		RecordConditionEvent(recorder, obj, c)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// recordedEvents - returns the events recorded by the fake recorder so far
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRecordConditionEvent(t *testing.T) {
+	obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns"}}
+
+	tests := []struct {
+		name string
+		c    *Condition
+		want []string
+	}{
+		{
+			name: "True condition",
+			c:    TrueCondition("a", "message trueA"),
+			want: []string{"Normal Ready a True: message trueA"},
+		},
+		{
+			name: "False condition",
+			c:    FalseCondition("a", ErrorReason, SeverityError, "message falseA"),
+			want: []string{"Warning Error a False: message falseA"},
+		},
+		{
+			name: "Unknown condition",
+			c:    UnknownCondition("a", RequestedReason, "message unknownA"),
+			want: []string{"Normal Requested a Unknown: message unknownA"},
+		},
+		{
+			name: "Condition without reason",
+			c:    &Condition{Type: "a", Status: corev1.ConditionFalse, Message: "message"},
+			want: []string{"Warning a a False: message"},
+		},
+		{
+			name: "Nil condition",
+			c:    nil,
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			recorder := record.NewFakeRecorder(10)
+			RecordConditionEvent(recorder, obj, tt.c)
+			g.Expect(recordedEvents(recorder)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestRecordTransitions(t *testing.T) {
+	g := NewWithT(t)
+	obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns"}}
+
+	oldConditions := CreateList(
+		UnknownCondition("a", RequestedReason, "message unknownA"),
+		TrueCondition("b", "message trueB"),
+	)
+	newConditions := CreateList(
+		FalseCondition("a", ErrorReason, SeverityError, "message falseA"),
+		TrueCondition("b", "message trueB"),
+		TrueCondition("c", "message trueC"),
+	)
+
+	recorder := record.NewFakeRecorder(10)
+	RecordTransitions(recorder, obj, oldConditions, newConditions)
+	g.Expect(recordedEvents(recorder)).To(Equal([]string{
+		"Warning Error a False: message falseA",
+		"Normal Ready c True: message trueC",
+	}))
+
+	// no transitions, no events
+	RecordTransitions(recorder, obj, newConditions, newConditions)
+	g.Expect(recordedEvents(recorder)).To(BeEmpty())
+}
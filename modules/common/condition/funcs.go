@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -45,6 +46,48 @@ func (conditions *Conditions) Init(cl *Conditions) {
 	}
 }
 
+// initMessages - the init messages of the common condition types, used by
+// InitFromTypes
+var initMessages = map[Type]string{
+	ReadyCondition:                     ReadyInitMessage,
+	InputReadyCondition:                InputReadyInitMessage,
+	ServiceConfigReadyCondition:        ServiceConfigReadyInitMessage,
+	DBReadyCondition:                   DBReadyInitMessage,
+	DBSyncReadyCondition:               DBSyncReadyInitMessage,
+	CreateServiceReadyCondition:        CreateServiceReadyInitMessage,
+	ExposeServiceReadyCondition:        ExposeServiceReadyInitMessage,
+	BootstrapReadyCondition:            BootstrapReadyInitMessage,
+	DeploymentReadyCondition:           DeploymentReadyInitMessage,
+	NetworkAttachmentsReadyCondition:   NetworkAttachmentsReadyInitMessage,
+	CronJobReadyCondition:              CronJobReadyInitMessage,
+	JobReadyCondition:                  JobReadyInitMessage,
+	MemcachedReadyCondition:            MemcachedReadyInitMessage,
+	RabbitMqTransportURLReadyCondition: RabbitMqTransportURLReadyInitMessage,
+	AnsibleEECondition:                 AnsibleEEReadyInitMessage,
+	ServiceAccountReadyCondition:       ServiceAccountReadyInitMessage,
+	RoleReadyCondition:                 RoleReadyInitMessage,
+	RoleBindingReadyCondition:          RoleBindingReadyInitMessage,
+	TopologyReadyCondition:             TopologyReadyInitMessage,
+}
+
+// InitFromTypes - init new condition list with the overall ReadyCondition
+// like Init, plus a condition with Status: Unknown and Reason: RequestedReason
+// for each of the passed types. Common condition types get their init message,
+// e.g. InputReadyInitMessage, other types a generic "<type> not started".
+// Duplicate types are only added once.
+func (conditions *Conditions) InitFromTypes(types ...Type) {
+	cl := Conditions{}
+	for _, t := range types {
+		msg, ok := initMessages[t]
+		if !ok {
+			msg = fmt.Sprintf("%s not started", t)
+		}
+		cl.Set(UnknownCondition(t, RequestedReason, "%s", msg))
+	}
+
+	conditions.Init(&cl)
+}
+
 // Set - sets new condition on the conditions list.
 //
 // If a condition already exists, the LastTransitionTime is only updated when there is a change
@@ -187,8 +230,9 @@ func (conditions *Conditions) Sort() {
 	// Sorts conditions for convenience of the consumer, i.e. cli client.
 	// According to this the Ready condition always goes first, followed by all the other
 	// conditions sorted by Type. This makes it easy to identify the overall state of
-	// the service
-	sort.Slice(*conditions, func(i, j int) bool {
+	// the service. The sort is stable so that conditions with the same Type
+	// keep their order and do not cause status changes between reconciles.
+	sort.SliceStable(*conditions, func(i, j int) bool {
 		return less(&(*conditions)[i], &(*conditions)[j])
 	})
 }
@@ -200,6 +244,34 @@ func (conditions *Conditions) SortByLastTransitionTime() {
 	})
 }
 
+// String - returns a deterministic representation of the conditions with one
+// line per condition, holding its Type, Status, Reason, Severity and Message.
+// The LastTransitionTime is not included. The conditions are sorted like Sort
+// does, conditions with the same Type are sorted by their content, so the
+// result does not depend on the order of the list.
+func (conditions Conditions) String() string {
+	lines := make([]string, 0, len(conditions))
+	sorted := slices.Clone(conditions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Type != sorted[j].Type {
+			return less(&sorted[i], &sorted[j])
+		}
+		return conditionString(&sorted[i]) < conditionString(&sorted[j])
+	})
+	for i := range sorted {
+		lines = append(lines, conditionString(&sorted[i]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// conditionString - returns the Type, Status, Reason, Severity and Message of
+// the condition in a single line.
+func conditionString(c *Condition) string {
+	return fmt.Sprintf("Type=%s Status=%s Reason=%s Severity=%s Message=%q",
+		c.Type, c.Status, c.Reason, c.Severity, c.Message)
+}
+
 // less returns true if a condition is less than another with regards to the
 // order of conditions designed for better consumption i.e. cli client.
 // According to this the Ready condition always goes first, followed by all the other
@@ -387,6 +459,155 @@ func (conditions *Conditions) Mirror(t Type) *Condition {
 	return mirrorCondition
 }
 
+// MostSevere returns the most severe condition of the list, excluding the
+// aggregated ReadyCondition. The conditions are prioritized by the same
+// order as Mirror uses, Status=False with Severity Error, Warning, Info,
+// followed by Status=Unknown and Status=True. If multiple conditions have the
+// same priority the one with the latest LastTransitionTime is returned.
+// It returns nil if there is no condition besides the ReadyCondition.
+func (conditions *Conditions) MostSevere() *Condition {
+	if conditions == nil || len(*conditions) == 0 {
+		return nil
+	}
+
+	for _, cg := range conditions.getConditionGroups() {
+		cl := Conditions{}
+		for _, c := range cg.conditions {
+			if c.Type != ReadyCondition {
+				cl = append(cl, c)
+			}
+		}
+		if len(cl) == 0 {
+			continue
+		}
+
+		// the first condition is the one with the latest LastTransitionTime
+		cl.SortByLastTransitionTime()
+		c := cl[0]
+		return &c
+	}
+
+	return nil
+}
+
+// TimeInState returns how long the condition has been in its current state,
+// which is the time passed between its LastTransitionTime and now. It returns
+// 0 if the LastTransitionTime is not set.
+func (c *Condition) TimeInState(now time.Time) time.Duration {
+	if c.LastTransitionTime.IsZero() {
+		return 0
+	}
+	return now.Sub(c.LastTransitionTime.Time)
+}
+
+// LongestNonReadyDuration returns the Type of the condition which is the
+// longest in a non True state and the duration it is in this state, excluding
+// the aggregated ReadyCondition. It returns an empty Type and 0 if all
+// conditions are True.
+func (conditions *Conditions) LongestNonReadyDuration(now time.Time) (Type, time.Duration) {
+	var longestType Type
+	var longest time.Duration
+
+	if conditions == nil {
+		return longestType, longest
+	}
+
+	for i := range *conditions {
+		c := &(*conditions)[i]
+		if c.Type == ReadyCondition || c.Status == corev1.ConditionTrue {
+			continue
+		}
+		if d := c.TimeInState(now); longestType == "" || d > longest {
+			longestType = c.Type
+			longest = d
+		}
+	}
+
+	return longestType, longest
+}
+
+// ClearErrorsAndMarkReady - marks all sub-conditions which are in Status=False
+// with SeverityError as True using successMsg. Afterwards the ReadyCondition is
+// marked True if all sub-conditions are True.
+// It should be called by the caller once the errors got resolved.
+func ClearErrorsAndMarkReady(conditions *Conditions, successMsg string) {
+	ClearErrorsAndMarkReadyFor(conditions, successMsg, func(Type) bool { return true })
+}
+
+// ClearErrorsAndMarkReadyFor - same as ClearErrorsAndMarkReady, but only clears
+// the error conditions for which clear returns true.
+func ClearErrorsAndMarkReadyFor(conditions *Conditions, successMsg string, clear func(Type) bool) {
+	if conditions == nil {
+		return
+	}
+
+	errorTypes := []Type{}
+	for _, c := range *conditions {
+		if c.Type == ReadyCondition {
+			continue
+		}
+		if c.Status == corev1.ConditionFalse && c.Severity == SeverityError && clear(c.Type) {
+			errorTypes = append(errorTypes, c.Type)
+		}
+	}
+
+	for _, t := range errorTypes {
+		conditions.MarkTrue(t, "%s", successMsg)
+	}
+
+	if conditions.AllSubConditionIsTrue() {
+		conditions.MarkTrue(ReadyCondition, ReadyMessage)
+	}
+}
+
+// RecalculateReady - sets the ReadyCondition from the sub-conditions. If all
+// sub-conditions are True, the ReadyCondition is marked True with readyMessage.
+// Otherwise the Status, Reason, Severity, Message and LastTransitionTime of
+// the most severe sub-condition, see MostSevere, get mirrored into the
+// ReadyCondition. It should be called at the end of a reconcile.
+func (conditions *Conditions) RecalculateReady(readyMessage string) {
+	if conditions == nil {
+		return
+	}
+
+	if conditions.AllSubConditionIsTrue() {
+		conditions.MarkTrue(ReadyCondition, "%s", readyMessage)
+		return
+	}
+
+	c := conditions.MostSevere()
+	ready := &Condition{
+		Type:               ReadyCondition,
+		Status:             c.Status,
+		Reason:             c.Reason,
+		Severity:           c.Severity,
+		Message:            c.Message,
+		LastTransitionTime: c.LastTransitionTime,
+	}
+	conditions.Set(ready)
+}
+
+// ValidateConsistency - returns an error if the ReadyCondition is True while
+// any sub-condition is False with SeverityError or SeverityWarning. It can be
+// called before patching the status to catch logic errors in the caller.
+func ValidateConsistency(conditions Conditions) error {
+	if !conditions.IsTrue(ReadyCondition) {
+		return nil
+	}
+
+	for _, c := range conditions {
+		if c.Type == ReadyCondition || c.Status != corev1.ConditionFalse {
+			continue
+		}
+		if c.Severity == SeverityError || c.Severity == SeverityWarning {
+			return fmt.Errorf("%s is True while %s is False with severity %s: %s",
+				ReadyCondition, c.Type, c.Severity, c.Message)
+		}
+	}
+
+	return nil
+}
+
 // RestoreLastTransitionTimes - Updates each condition's LastTransitionTime when its state
 // matches the one in a list of "saved" conditions.
 func RestoreLastTransitionTimes(conditions *Conditions, savedConditions Conditions) {
@@ -94,6 +94,53 @@ func TestInit(t *testing.T) {
 	}
 }
 
+func TestInitFromTypes(t *testing.T) {
+	unknownInputReady := UnknownCondition(InputReadyCondition, RequestedReason, InputReadyInitMessage)
+	unknownDBReady := UnknownCondition(DBReadyCondition, RequestedReason, DBReadyInitMessage)
+	unknownCustom := UnknownCondition("Custom", RequestedReason, "Custom not started")
+
+	tests := []struct {
+		name  string
+		types []Type
+		want  Conditions
+	}{
+		{
+			name:  "Init conditions without types",
+			types: nil,
+			want:  CreateList(unknownReady),
+		},
+		{
+			name:  "Init conditions with a type",
+			types: []Type{InputReadyCondition},
+			want:  CreateList(unknownReady, unknownInputReady),
+		},
+		{
+			name:  "Init conditions with types",
+			types: []Type{InputReadyCondition, DBReadyCondition, "Custom"},
+			want:  CreateList(unknownReady, unknownCustom, unknownDBReady, unknownInputReady),
+		},
+		{
+			name:  "Init conditions with duplicate types",
+			types: []Type{DBReadyCondition, InputReadyCondition, DBReadyCondition, ReadyCondition},
+			want:  CreateList(unknownReady, unknownDBReady, unknownInputReady),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			someCondition := TrueCondition("foo", "to be removed on InitFromTypes()")
+			conditions := Conditions{
+				*someCondition,
+			}
+
+			conditions.InitFromTypes(tt.types...)
+			g.Expect(conditions).To(haveSameConditionsOf(tt.want))
+		})
+	}
+}
+
 func TestSet(t *testing.T) {
 	conditions := Conditions{}
 
@@ -434,6 +481,220 @@ func TestMarkMethods(t *testing.T) {
 	g.Expect(conditions.Get("a")).To(haveSameStateOf(unknownA))
 }
 
+func TestClearErrorsAndMarkReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions Conditions
+		clear      func(Type) bool
+		want       Conditions
+	}{
+		{
+			name:       "Clear all errors",
+			conditions: CreateList(falseError, falseBError, trueA),
+			want: CreateList(
+				trueReady,
+				trueA,
+				TrueCondition("b", "resolved"),
+				TrueCondition("falseError", "resolved"),
+			),
+		},
+		{
+			name:       "Clear errors but keep info and unknown conditions",
+			conditions: CreateList(falseError, falseInfo, unknownA),
+			want: CreateList(
+				unknownReady,
+				unknownA,
+				TrueCondition("falseError", "resolved"),
+				falseInfo,
+			),
+		},
+		{
+			name:       "Clear errors for selected types only",
+			conditions: CreateList(falseError, falseBError, trueA),
+			clear: func(t Type) bool {
+				return t == "b"
+			},
+			want: CreateList(
+				unknownReady,
+				trueA,
+				TrueCondition("b", "resolved"),
+				falseError,
+			),
+		},
+		{
+			name:       "No errors, all true",
+			conditions: CreateList(trueA, trueB),
+			want:       CreateList(trueReady, trueA, trueB),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			conditions := Conditions{}
+			conditions.Init(&tt.conditions)
+
+			if tt.clear != nil {
+				ClearErrorsAndMarkReadyFor(&conditions, "resolved", tt.clear)
+			} else {
+				ClearErrorsAndMarkReady(&conditions, "resolved")
+			}
+			g.Expect(conditions).To(haveSameConditionsOf(tt.want))
+		})
+	}
+
+	t.Run("nil conditions", func(t *testing.T) {
+		ClearErrorsAndMarkReady(nil, "resolved")
+	})
+}
+
+func TestRecalculateReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions Conditions
+		want       *Condition
+	}{
+		{
+			name:       "All sub-conditions True",
+			conditions: CreateList(unknownReady, trueA, trueB),
+			want:       TrueCondition(ReadyCondition, "all good"),
+		},
+		{
+			name:       "No sub-conditions",
+			conditions: CreateList(unknownReady),
+			want:       TrueCondition(ReadyCondition, "all good"),
+		},
+		{
+			name:       "One False with Error",
+			conditions: CreateList(trueReady, trueA, unknownB, falseError),
+			want:       FalseCondition(ReadyCondition, falseError.Reason, SeverityError, "%s", falseError.Message),
+		},
+		{
+			name:       "One Unknown",
+			conditions: CreateList(trueReady, trueA, unknownB),
+			want:       UnknownCondition(ReadyCondition, unknownB.Reason, "%s", unknownB.Message),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			conditions := tt.conditions.DeepCopy()
+			conditions.RecalculateReady("all good")
+			g.Expect(conditions.Get(ReadyCondition)).To(haveSameStateOf(tt.want))
+			g.Expect(conditions).To(HaveLen(len(tt.conditions)))
+		})
+	}
+}
+
+func TestValidateConsistency(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions Conditions
+		wantErr    bool
+	}{
+		{
+			name:       "Ready True, all sub-conditions True",
+			conditions: CreateList(trueReady, trueA, trueB),
+			wantErr:    false,
+		},
+		{
+			name:       "Ready True with Info and Unknown sub-conditions",
+			conditions: CreateList(trueReady, falseInfo, unknownA),
+			wantErr:    false,
+		},
+		{
+			name:       "Ready Unknown with Error sub-condition",
+			conditions: CreateList(unknownReady, falseError),
+			wantErr:    false,
+		},
+		{
+			name:       "Ready True with Error sub-condition",
+			conditions: CreateList(trueReady, trueA, falseError),
+			wantErr:    true,
+		},
+		{
+			name:       "Ready True with Warning sub-condition",
+			conditions: CreateList(trueReady, falseWarning),
+			wantErr:    true,
+		},
+		{
+			name:       "Empty list",
+			conditions: Conditions{},
+			wantErr:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := ValidateConsistency(tt.conditions)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestSortStable(t *testing.T) {
+	g := NewWithT(t)
+
+	// sort.Slice is only unstable for more than 12 elements
+	conditions := Conditions{}
+	want := Conditions{*unknownReady}
+	wantB := Conditions{}
+	for i := 0; i < 10; i++ {
+		b := UnknownCondition("b", "reason", "message %d", i)
+		a := UnknownCondition("a", "reason", "message %d", i)
+		conditions = append(conditions, *b, *a)
+		want = append(want, *a)
+		wantB = append(wantB, *b)
+	}
+	conditions = append(conditions, *unknownReady)
+	want = append(want, wantB...)
+
+	conditions.Sort()
+	g.Expect(conditions).To(Equal(want))
+}
+
+func TestConditionsString(t *testing.T) {
+	g := NewWithT(t)
+
+	time1 := metav1.NewTime(time.Date(2020, time.August, 9, 10, 0, 0, 0, time.UTC))
+	time2 := metav1.NewTime(time.Date(2020, time.August, 10, 10, 0, 0, 0, time.UTC))
+
+	withTime := func(c *Condition, ltt metav1.Time) *Condition {
+		cc := *c
+		cc.LastTransitionTime = ltt
+		return &cc
+	}
+
+	cl1 := CreateList(withTime(falseA, time1), unknownReady, unknownB, withTime(falseError, time2))
+	cl2 := CreateList(unknownB, withTime(falseError, time1), withTime(falseA, time2), unknownReady)
+
+	g.Expect(cl1.String()).To(Equal(cl2.String()))
+	g.Expect(cl1.String()).To(Equal(
+		`Type=Ready Status=Unknown Reason=Requested Severity= Message="` + ReadyInitMessage + `"` + "\n" +
+			`Type=a Status=False Reason=reason falseA Severity=Info Message="message falseA"` + "\n" +
+			`Type=b Status=Unknown Reason=reason unknownB Severity= Message="message unknownB"` + "\n" +
+			`Type=falseError Status=False Reason=reason falseError Severity=Error Message="message falseError"`))
+
+	// conditions with the same Type are sorted by their content
+	cl1 = CreateList(falseA, trueA)
+	cl2 = CreateList(trueA, falseA)
+	g.Expect(cl1.String()).To(Equal(cl2.String()))
+
+	// the list itself does not get sorted
+	g.Expect(cl2[0].Status).To(Equal(corev1.ConditionTrue))
+
+	g.Expect(Conditions{}.String()).To(BeEmpty())
+}
+
 func TestSortByLastTransitionTime(t *testing.T) {
 	g := NewWithT(t)
 
@@ -517,6 +778,139 @@ func TestMirror(t *testing.T) {
 	g.Expect(targetCondition.Message).To(BeIdenticalTo(trueReady.Message))
 }
 
+func TestMostSevere(t *testing.T) {
+	time1 := metav1.NewTime(time.Date(2020, time.August, 9, 10, 0, 0, 0, time.UTC))
+	time2 := metav1.NewTime(time.Date(2020, time.August, 10, 10, 0, 0, 0, time.UTC))
+
+	// copies of the fixtures with a LastTransitionTime set
+	withTime := func(c *Condition, ltt metav1.Time) *Condition {
+		cc := *c
+		cc.LastTransitionTime = ltt
+		return &cc
+	}
+
+	tests := []struct {
+		name       string
+		conditions Conditions
+		want       *Condition
+	}{
+		{
+			name:       "Empty list",
+			conditions: Conditions{},
+			want:       nil,
+		},
+		{
+			name:       "Only ReadyCondition",
+			conditions: CreateList(unknownReady),
+			want:       nil,
+		},
+		{
+			name:       "Error before Warning",
+			conditions: CreateList(unknownReady, falseWarning, falseError, unknownB),
+			want:       falseError,
+		},
+		{
+			name:       "Warning before Unknown",
+			conditions: CreateList(unknownReady, unknownB, falseWarning, trueA),
+			want:       falseWarning,
+		},
+		{
+			name:       "Unknown before True, ignoring the ReadyCondition",
+			conditions: CreateList(trueReady, trueA, unknownB),
+			want:       unknownB,
+		},
+		{
+			name: "Same priority, latest LastTransitionTime wins",
+			conditions: CreateList(
+				unknownReady,
+				withTime(falseError, time1),
+				withTime(falseBError, time2),
+			),
+			want: withTime(falseBError, time2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := tt.conditions.MostSevere()
+			if tt.want == nil {
+				g.Expect(c).To(BeNil())
+				return
+			}
+			g.Expect(c).NotTo(BeNil())
+			g.Expect(c.Type).To(Equal(tt.want.Type))
+			g.Expect(c.Status).To(Equal(tt.want.Status))
+			g.Expect(c.Severity).To(Equal(tt.want.Severity))
+			g.Expect(c.LastTransitionTime).To(Equal(tt.want.LastTransitionTime))
+		})
+	}
+}
+
+func TestTimeInState(t *testing.T) {
+	now := time.Date(2020, time.August, 10, 10, 0, 0, 0, time.UTC)
+	time1 := metav1.NewTime(now.Add(-2 * time.Hour))
+	time2 := metav1.NewTime(now.Add(-30 * time.Minute))
+	time3 := metav1.NewTime(now.Add(-5 * time.Hour))
+
+	withTime := func(c *Condition, ltt metav1.Time) *Condition {
+		cc := *c
+		cc.LastTransitionTime = ltt
+		return &cc
+	}
+
+	g := NewWithT(t)
+
+	g.Expect(withTime(falseA, time1).TimeInState(now)).To(Equal(2 * time.Hour))
+	g.Expect(withTime(trueA, time2).TimeInState(now)).To(Equal(30 * time.Minute))
+	g.Expect(TrueCondition("a", "message trueA").TimeInState(now)).To(Equal(time.Duration(0)))
+
+	tests := []struct {
+		name       string
+		conditions Conditions
+		wantType   Type
+		wantDur    time.Duration
+	}{
+		{
+			name:       "Empty list",
+			conditions: Conditions{},
+			wantType:   "",
+			wantDur:    0,
+		},
+		{
+			name: "All True, ignoring a not ready ReadyCondition",
+			conditions: CreateList(
+				withTime(unknownReady, time3),
+				withTime(trueA, time1),
+			),
+			wantType: "",
+			wantDur:  0,
+		},
+		{
+			name: "Longest non True condition",
+			conditions: CreateList(
+				withTime(unknownReady, time3),
+				withTime(trueA, time3),
+				withTime(unknownB, time2),
+				withTime(falseError, time1),
+			),
+			wantType: falseError.Type,
+			wantDur:  2 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ct, d := tt.conditions.LongestNonReadyDuration(now)
+			g.Expect(ct).To(Equal(tt.wantType))
+			g.Expect(d).To(Equal(tt.wantDur))
+		})
+	}
+}
+
 func TestMirrorInvalidStatus(t *testing.T) {
 	g := NewWithT(t)
 
@@ -19,12 +19,15 @@ package configmap
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -82,6 +85,18 @@ func createOrPatchConfigMap(
 			return err
 		}
 		configMap.Data = renderedTemplateData
+		// raw files which are not valid UTF-8 can only be stored as BinaryData,
+		// which gets rebuilt to drop keys no longer rendered as binary
+		configMap.BinaryData = nil
+		for _, k := range cm.RawFiles {
+			if v, ok := configMap.Data[k]; ok && !utf8.ValidString(v) {
+				if configMap.BinaryData == nil {
+					configMap.BinaryData = map[string][]byte{}
+				}
+				configMap.BinaryData[k] = []byte(v)
+				delete(configMap.Data, k)
+			}
+		}
 		// add provided custom data to configMap.Data
 		// Note: this can overwrite data rendered from GetTemplateData() if key is same
 		if len(cm.CustomData) > 0 {
@@ -93,14 +108,21 @@ func createOrPatchConfigMap(
 					h.GetLogger().Info(fmt.Sprintf("Skipped customData expansion due to: %s", err))
 					configMap.Data[k] = v
 				}
+				delete(configMap.BinaryData, k)
 			}
 		}
 
-		if !cm.SkipSetOwner {
-			err := controllerutil.SetControllerReference(obj, configMap, h.GetScheme())
-			if err != nil {
-				return err
+		// Only set controller ref if namespaces are equal, else we hit an error
+		if obj.GetNamespace() == configMap.Namespace {
+			if !cm.SkipSetOwner {
+				err := controllerutil.SetControllerReference(obj, configMap, h.GetScheme())
+				if err != nil {
+					return err
+				}
 			}
+		} else {
+			// Set ownership labels that can be found by the respective controller kind
+			configMap.SetLabels(labels.Merge(configMap.GetLabels(), ownerLabels(obj, cm)))
 		}
 
 		return nil
@@ -117,6 +139,18 @@ func createOrPatchConfigMap(
 	return configMapHash, op, nil
 }
 
+// ownerLabels - returns the labels used to track the owner of a configmap
+// which is created in a different namespace than the owner, as owner
+// references can not be set across namespaces.
+func ownerLabels(obj client.Object, cm util.Template) map[string]string {
+	ownerLabel := fmt.Sprintf("%s.%s", strings.ToLower(cm.InstanceType), obj.GetObjectKind().GroupVersionKind().Group)
+	return map[string]string{
+		ownerLabel + "/uid":       string(obj.GetUID()),
+		ownerLabel + "/namespace": obj.GetNamespace(),
+		ownerLabel + "/name":      obj.GetName(),
+	}
+}
+
 // createOrGetCustomConfigMap -
 func createOrGetCustomConfigMap(
 	ctx context.Context,
@@ -137,11 +171,16 @@ func createOrGetCustomConfigMap(
 	foundConfigMap := &corev1.ConfigMap{}
 	err := h.GetClient().Get(ctx, types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, foundConfigMap)
 	if err != nil && k8s_errors.IsNotFound(err) {
-		if !cm.SkipSetOwner {
-			err := controllerutil.SetControllerReference(obj, configMap, h.GetScheme())
-			if err != nil {
-				return "", err
+		// Only set controller ref if namespaces are equal, else we hit an error
+		if obj.GetNamespace() == configMap.Namespace {
+			if !cm.SkipSetOwner {
+				err := controllerutil.SetControllerReference(obj, configMap, h.GetScheme())
+				if err != nil {
+					return "", err
+				}
 			}
+		} else {
+			configMap.SetLabels(labels.Merge(configMap.GetLabels(), ownerLabels(obj, cm)))
 		}
 
 		h.GetLogger().Info(fmt.Sprintf("Creating a new ConfigMap %s in namespace %s", cm.Namespace, cm.Name))
@@ -149,9 +188,12 @@ func createOrGetCustomConfigMap(
 		if err != nil {
 			return "", err
 		}
+	} else if err != nil {
+		return "", err
 	} else {
 		// use data from already existing custom configmap
 		configMap.Data = foundConfigMap.Data
+		configMap.BinaryData = foundConfigMap.BinaryData
 	}
 
 	configMapHash, err := Hash(configMap)
@@ -271,6 +313,38 @@ func GetConfigMap(
 	return configMap, ctrl.Result{}, nil
 }
 
+// GetDataFromConfigMap - Get data from ConfigMap
+//
+// if the configmap is not found, requeue after requeueTimeout. If the key is
+// not found in the configmap an error is returned.
+func GetDataFromConfigMap(
+	ctx context.Context,
+	h *helper.Helper,
+	cmName string,
+	requeueTimeout time.Duration,
+	key string,
+) (string, ctrl.Result, error) {
+
+	data := ""
+
+	configMap, ctrlResult, err := GetConfigMap(ctx, h, h.GetBeforeObject(), cmName, requeueTimeout)
+	if err != nil {
+		return data, ctrl.Result{}, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return data, ctrlResult, nil
+	}
+
+	if key != "" {
+		val, ok := configMap.Data[key]
+		if !ok {
+			return data, ctrl.Result{}, fmt.Errorf("%s not found in config map %s", key, cmName)
+		}
+		data = strings.TrimSuffix(val, "\n")
+	}
+
+	return data, ctrl.Result{}, nil
+}
+
 // VerifyConfigMap - verifies if the ConfigMap object exists and the expected fields
 // are in the ConfigMap. It returns a hash of the values of the expected fields.
 func VerifyConfigMap(
@@ -24,6 +24,7 @@ import (
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -127,3 +128,18 @@ func GetDeploymentWithName(
 
 	return depl, nil
 }
+
+// IsProgressDeadlineExceeded - returns true and the condition message if the
+// DeploymentProgressing condition reports that the deployment failed to
+// progress within its progressDeadlineSeconds.
+func IsProgressDeadlineExceeded(depl *appsv1.Deployment) (bool, string) {
+	for _, c := range depl.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing &&
+			c.Status == corev1.ConditionFalse &&
+			c.Reason == ProgressDeadlineExceededReason {
+			return true, c.Message
+		}
+	}
+
+	return false, ""
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestIsProgressDeadlineExceeded(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []appsv1.DeploymentCondition
+		want       bool
+		wantMsg    string
+	}{
+		{
+			name:       "No conditions",
+			conditions: nil,
+			want:       false,
+			wantMsg:    "",
+		},
+		{
+			name: "Progressing",
+			conditions: []appsv1.DeploymentCondition{
+				{
+					Type:    appsv1.DeploymentProgressing,
+					Status:  corev1.ConditionTrue,
+					Reason:  "NewReplicaSetAvailable",
+					Message: "ReplicaSet \"foo-1234\" has successfully progressed.",
+				},
+			},
+			want:    false,
+			wantMsg: "",
+		},
+		{
+			name: "Progress deadline exceeded",
+			conditions: []appsv1.DeploymentCondition{
+				{
+					Type:   appsv1.DeploymentAvailable,
+					Status: corev1.ConditionFalse,
+					Reason: "MinimumReplicasUnavailable",
+				},
+				{
+					Type:    appsv1.DeploymentProgressing,
+					Status:  corev1.ConditionFalse,
+					Reason:  ProgressDeadlineExceededReason,
+					Message: "ReplicaSet \"foo-1234\" has timed out progressing.",
+				},
+			},
+			want:    true,
+			wantMsg: "ReplicaSet \"foo-1234\" has timed out progressing.",
+		},
+		{
+			name: "Progressing false for other reason",
+			conditions: []appsv1.DeploymentCondition{
+				{
+					Type:   appsv1.DeploymentProgressing,
+					Status: corev1.ConditionFalse,
+					Reason: "ReplicaSetCreateError",
+				},
+			},
+			want:    false,
+			wantMsg: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			depl := &appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{
+					Conditions: tt.conditions,
+				},
+			}
+			exceeded, msg := IsProgressDeadlineExceeded(depl)
+			g.Expect(exceeded).To(Equal(tt.want))
+			g.Expect(msg).To(Equal(tt.wantMsg))
+		})
+	}
+}
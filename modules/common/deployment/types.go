@@ -22,6 +22,12 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 )
 
+const (
+	// ProgressDeadlineExceededReason - reason of the DeploymentProgressing
+	// condition if the deployment failed to progress within progressDeadlineSeconds
+	ProgressDeadlineExceededReason = "ProgressDeadlineExceeded"
+)
+
 // Deployment -
 type Deployment struct {
 	deployment *appsv1.Deployment
@@ -32,7 +32,8 @@ type Setter func(*corev1.EnvVar)
 // SetterMap - env setter map
 type SetterMap map[string]Setter
 
-// MergeEnvs - merge envs
+// MergeEnvs - merge envs. Existing envs are updated by the setter with the
+// same name, new envs are appended. Envs set via Remove are deleted.
 func MergeEnvs(envs []corev1.EnvVar, newEnvs SetterMap) []corev1.EnvVar {
 
 	// as there is no sorted order when look over hashmap,
@@ -45,20 +46,37 @@ func MergeEnvs(envs []corev1.EnvVar, newEnvs SetterMap) []corev1.EnvVar {
 		for i := 0; i < len(envs); i++ {
 			if envs[i].Name == newEnv.Key {
 				newEnv.Value(&envs[i])
+				// the setter cleared the name, so the env got removed
+				if envs[i].Name == "" {
+					envs = append(envs[:i], envs[i+1:]...)
+				}
 				updated = true
 				break
 			}
 		}
 
 		if !updated {
-			envs = append(envs, corev1.EnvVar{Name: newEnv.Key})
-			newEnv.Value(&envs[len(envs)-1])
+			env := corev1.EnvVar{Name: newEnv.Key}
+			newEnv.Value(&env)
+			if env.Name != "" {
+				envs = append(envs, env)
+			}
 		}
 	}
 
 	return envs
 }
 
+// Remove - removes the env with name from the envs when used with MergeEnvs.
+// Removing an env which does not exist is a no-op.
+func Remove(name string) Setter {
+	return func(env *corev1.EnvVar) {
+		if env.Name == name {
+			env.Name = ""
+		}
+	}
+}
+
 // SetValue - set env value
 func SetValue(value string) Setter {
 	return func(env *corev1.EnvVar) {
@@ -111,3 +129,33 @@ func DownwardAPI(field string) Setter {
 		env.ValueFrom.FieldRef.FieldPath = field
 	}
 }
+
+// SetFieldRef - set env from the pod field fieldPath via the downward API,
+// e.g. status.podIP or spec.nodeName. Other than DownwardAPI any previous
+// value or source of the env gets replaced.
+func SetFieldRef(fieldPath string) Setter {
+	return func(env *corev1.EnvVar) {
+		env.Value = ""
+		env.ValueFrom = &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				APIVersion: "v1",
+				FieldPath:  fieldPath,
+			},
+		}
+	}
+}
+
+// SetResourceFieldRef - set env from the resource, e.g. limits.memory, of the
+// container via the downward API. Any previous value or source of the env
+// gets replaced.
+func SetResourceFieldRef(container string, resource string) Setter {
+	return func(env *corev1.EnvVar) {
+		env.Value = ""
+		env.ValueFrom = &corev1.EnvVarSource{
+			ResourceFieldRef: &corev1.ResourceFieldSelector{
+				ContainerName: container,
+				Resource:      resource,
+			},
+		}
+	}
+}
@@ -68,6 +68,49 @@ func TestMergeEnvs(t *testing.T) {
 				{Name: "04", Value: "FOURTH_VALUE"},
 			},
 		},
+		{
+			name: "Overwrite multiple values including a DownwardAPI env",
+			envs: map[string]Setter{
+				"01": DownwardAPI("status.podIP"),
+				"03": SetValue("THIRD_UPDATED_VALUE"),
+			},
+			want: []corev1.EnvVar{
+				{Name: "01", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"}}},
+				{Name: "02", Value: "SECOND_UPDATED_VALUE"},
+				{Name: "03", Value: "THIRD_UPDATED_VALUE"},
+				{Name: "04", Value: "FOURTH_VALUE"},
+			},
+		},
+		{
+			name: "Remove an existing env",
+			envs: map[string]Setter{"02": Remove("02")},
+			want: []corev1.EnvVar{
+				{Name: "01", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"}}},
+				{Name: "03", Value: "THIRD_UPDATED_VALUE"},
+				{Name: "04", Value: "FOURTH_VALUE"},
+			},
+		},
+		{
+			name: "Remove a not existing env",
+			envs: map[string]Setter{"05": Remove("05")},
+			want: []corev1.EnvVar{
+				{Name: "01", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"}}},
+				{Name: "03", Value: "THIRD_UPDATED_VALUE"},
+				{Name: "04", Value: "FOURTH_VALUE"},
+			},
+		},
+		{
+			name: "Remove and add envs",
+			envs: map[string]Setter{
+				"01": Remove("01"),
+				"04": Remove("04"),
+				"05": SetValue("FIFTH_VALUE"),
+			},
+			want: []corev1.EnvVar{
+				{Name: "03", Value: "THIRD_UPDATED_VALUE"},
+				{Name: "05", Value: "FIFTH_VALUE"},
+			},
+		},
 	}
 
 	mergedEnvs := []corev1.EnvVar{}
@@ -82,3 +125,70 @@ func TestMergeEnvs(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldRefSetters(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    corev1.EnvVar
+		setter Setter
+		want   corev1.EnvVar
+	}{
+		{
+			name:   "SetFieldRef",
+			env:    corev1.EnvVar{Name: "POD_IP"},
+			setter: SetFieldRef("status.podIP"),
+			want: corev1.EnvVar{
+				Name: "POD_IP",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						APIVersion: "v1",
+						FieldPath:  "status.podIP",
+					},
+				},
+			},
+		},
+		{
+			name: "SetFieldRef replaces the previous value",
+			env: corev1.EnvVar{
+				Name:  "NODE_NAME",
+				Value: "foo",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{Resource: "limits.cpu"},
+				},
+			},
+			setter: SetFieldRef("spec.nodeName"),
+			want: corev1.EnvVar{
+				Name: "NODE_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						APIVersion: "v1",
+						FieldPath:  "spec.nodeName",
+					},
+				},
+			},
+		},
+		{
+			name:   "SetResourceFieldRef",
+			env:    corev1.EnvVar{Name: "MEMORY_LIMIT", Value: "foo"},
+			setter: SetResourceFieldRef("api", "limits.memory"),
+			want: corev1.EnvVar{
+				Name: "MEMORY_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: "api",
+						Resource:      "limits.memory",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			envs := MergeEnvs([]corev1.EnvVar{tt.env}, SetterMap{tt.env.Name: tt.setter})
+			g.Expect(envs).To(Equal([]corev1.EnvVar{tt.want}))
+		})
+	}
+}
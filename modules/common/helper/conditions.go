@@ -0,0 +1,40 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+)
+
+// ConditionsGetter - an object exposing its status conditions
+type ConditionsGetter interface {
+	GetConditions() condition.Conditions
+}
+
+// SnapshotConditions - returns a copy of the current conditions of obj. Take
+// the snapshot at the start of reconcile, before the conditions get
+// re-initialized, and pass it to RestoreConditions before updating the status.
+func SnapshotConditions(obj ConditionsGetter) condition.Conditions {
+	return obj.GetConditions().DeepCopy()
+}
+
+// RestoreConditions - restores the LastTransitionTime of all conditions which
+// did not change their state compared to the snapshot taken with
+// SnapshotConditions.
+func RestoreConditions(conditions *condition.Conditions, snapshot condition.Conditions) {
+	condition.RestoreLastTransitionTimes(conditions, snapshot)
+}
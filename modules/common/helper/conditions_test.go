@@ -0,0 +1,79 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testInstance struct {
+	conditions condition.Conditions
+}
+
+func (i *testInstance) GetConditions() condition.Conditions {
+	return i.conditions
+}
+
+func TestSnapshotConditions(t *testing.T) {
+	g := NewWithT(t)
+
+	past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	instance := &testInstance{
+		conditions: condition.Conditions{
+			{
+				Type:               condition.ReadyCondition,
+				Status:             corev1.ConditionFalse,
+				Severity:           condition.SeverityInfo,
+				Reason:             condition.RequestedReason,
+				Message:            condition.ReadyInitMessage,
+				LastTransitionTime: past,
+			},
+			{
+				Type:               condition.InputReadyCondition,
+				Status:             corev1.ConditionTrue,
+				Reason:             condition.ReadyReason,
+				Message:            condition.InputReadyMessage,
+				LastTransitionTime: past,
+			},
+		},
+	}
+
+	// start of reconcile
+	snapshot := SnapshotConditions(instance)
+
+	// the snapshot is not affected by the conditions being re-initialized
+	instance.conditions = condition.Conditions{}
+	instance.conditions.Init(nil)
+	instance.conditions.MarkTrue(condition.InputReadyCondition, condition.InputReadyMessage)
+	instance.conditions.MarkTrue(condition.ReadyCondition, condition.ReadyMessage)
+	g.Expect(snapshot).To(HaveLen(2))
+	g.Expect(snapshot.Get(condition.InputReadyCondition).LastTransitionTime).To(Equal(past))
+
+	// before the status update
+	RestoreConditions(&instance.conditions, snapshot)
+
+	// unchanged condition keeps its LastTransitionTime
+	g.Expect(instance.conditions.Get(condition.InputReadyCondition).LastTransitionTime).To(Equal(past))
+	// changed condition gets a new LastTransitionTime
+	g.Expect(instance.conditions.Get(condition.ReadyCondition).LastTransitionTime).NotTo(Equal(past))
+}
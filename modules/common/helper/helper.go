@@ -19,17 +19,23 @@ package helper
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
@@ -122,6 +128,16 @@ func (h *Helper) GetLogger() logr.Logger {
 	return h.logger
 }
 
+// LoggerForObject - returns the logger with the kind, namespace and name of
+// the reconciled object attached as key/values
+func (h *Helper) LoggerForObject() logr.Logger {
+	return h.logger.WithValues(
+		"ObjectKind", h.gvk.Kind,
+		"ObjectNamespace", h.beforeObject.GetNamespace(),
+		"ObjectName", h.beforeObject.GetName(),
+	)
+}
+
 // GetFinalizer - returns the finalizer
 func (h *Helper) GetFinalizer() string {
 	return h.finalizer
@@ -229,6 +245,140 @@ func (h *Helper) PatchInstance(ctx context.Context, instance client.Object) erro
 	return nil
 }
 
+// CreateOrPatchObject - creates or patches obj using controllerutil.CreateOrPatch.
+// The mutate function is called to apply the desired state to obj, afterwards
+// the object being reconciled is set as controller reference of obj. If the
+// object is not found, e.g. because its namespace does not exist (yet), a
+// requeue after timeout is returned instead of an error.
+func CreateOrPatchObject(
+	ctx context.Context,
+	h *Helper,
+	obj client.Object,
+	timeout time.Duration,
+	mutate controllerutil.MutateFn,
+) (ctrl.Result, controllerutil.OperationResult, error) {
+	op, err := controllerutil.CreateOrPatch(ctx, h.GetClient(), obj, func() error {
+		if mutate != nil {
+			if err := mutate(); err != nil {
+				return err
+			}
+		}
+
+		return controllerutil.SetControllerReference(h.GetBeforeObject(), obj, h.GetScheme())
+	})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			h.GetLogger().Info(fmt.Sprintf("%T %s not found, reconcile in %s", obj, obj.GetName(), timeout))
+			return RequeueAfter(timeout), op, nil
+		}
+		return ctrl.Result{}, op, err
+	}
+	if op != controllerutil.OperationResultNone {
+		h.GetLogger().Info(fmt.Sprintf("%T %s - %s", obj, obj.GetName(), op))
+	}
+
+	return ctrl.Result{}, op, nil
+}
+
+// EnsureFinalizer - adds the finalizer of the helper to obj, using a merge
+// patch of only the finalizers with optimistic locking, so finalizers changed
+// concurrently by others do not get lost. On conflict obj gets refreshed and
+// the patch retried. Returns true if the finalizer got added.
+func EnsureFinalizer(ctx context.Context, h *Helper, obj client.Object) (bool, error) {
+	return patchFinalizers(ctx, h, obj, controllerutil.AddFinalizer)
+}
+
+// RemoveFinalizer - removes the finalizer of the helper from obj, like
+// EnsureFinalizer using a merge patch of only the finalizers with optimistic
+// locking. Returns true if the finalizer got removed.
+func RemoveFinalizer(ctx context.Context, h *Helper, obj client.Object) (bool, error) {
+	return patchFinalizers(ctx, h, obj, controllerutil.RemoveFinalizer)
+}
+
+// patchFinalizers - applies the finalizer change to obj and patches obj if
+// the finalizers changed, retrying with the refreshed obj on conflict
+func patchFinalizers(
+	ctx context.Context,
+	h *Helper,
+	obj client.Object,
+	change func(client.Object, string) bool,
+) (bool, error) {
+	changed := false
+	refresh := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if refresh {
+			if err := h.GetClient().Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return err
+			}
+		}
+		refresh = true
+
+		base := obj.DeepCopyObject().(client.Object)
+		changed = change(obj, h.GetFinalizer())
+		if !changed {
+			return nil
+		}
+
+		return h.GetClient().Patch(ctx, obj, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+	if err != nil {
+		return false, fmt.Errorf("error patching finalizers of %T %s: %w", obj, obj.GetName(), err)
+	}
+
+	return changed, nil
+}
+
+// ListWithLabelPaginated - lists the objects of the type of listObj in
+// namespace which match the labelSelectorMap, in pages of at most limit items
+// using the continue token of the list, and calls fn for every item. This keeps
+// the memory usage low when there are many objects. listObj gets reused for
+// every page, fn must not keep a reference to the item after it returned.
+// NOTE: reader has to read from the API server, e.g. the mgr.GetAPIReader().
+// The cached client of a controller-runtime manager does not support Continue,
+// it applies the limit without returning a continue token.
+func ListWithLabelPaginated(
+	ctx context.Context,
+	reader client.Reader,
+	listObj client.ObjectList,
+	namespace string,
+	labelSelectorMap map[string]string,
+	limit int64,
+	fn func(client.Object) error,
+) error {
+	listOpts := []client.ListOption{
+		client.InNamespace(namespace),
+		client.MatchingLabels(labelSelectorMap),
+		client.Limit(limit),
+	}
+
+	continueToken := ""
+	for {
+		err := reader.List(ctx, listObj, append(listOpts, client.Continue(continueToken))...)
+		if err != nil {
+			return fmt.Errorf("error listing %T with labels %v: %w", listObj, labelSelectorMap, err)
+		}
+
+		items, err := meta.ExtractList(listObj)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				return fmt.Errorf("%T is not a client.Object", item)
+			}
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+
+		continueToken = listObj.GetContinue()
+		if continueToken == "" {
+			return nil
+		}
+	}
+}
+
 // ToUnstructured - convert to unstructured
 func ToUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
 	// If the incoming object is already unstructured, perform a deep copy first
@@ -17,13 +17,27 @@ limitations under the License.
 package helper
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestToUnstructured(t *testing.T) {
@@ -85,3 +99,125 @@ func TestToUnstructured(t *testing.T) {
 		g.Expect(obj.GetName()).To(Equal("keystone"))
 	})
 }
+
+// recordingSink - logr.LogSink recording the key/values attached to the logger
+type recordingSink struct {
+	values []interface{}
+}
+
+func (s *recordingSink) Init(logr.RuntimeInfo)               {}
+func (s *recordingSink) Enabled(int) bool                    { return true }
+func (s *recordingSink) Info(int, string, ...interface{})    {}
+func (s *recordingSink) Error(error, string, ...interface{}) {}
+func (s *recordingSink) WithName(string) logr.LogSink        { return s }
+func (s *recordingSink) WithValues(kv ...interface{}) logr.LogSink {
+	return &recordingSink{values: append(append([]interface{}{}, s.values...), kv...)}
+}
+
+func TestLoggerForObject(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "keystone",
+			Namespace: "openstack",
+		},
+	}
+	h := &Helper{
+		gvk:          schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		beforeObject: obj,
+		logger:       logr.New(&recordingSink{}),
+	}
+
+	sink, ok := h.LoggerForObject().GetSink().(*recordingSink)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(sink.values).To(Equal([]interface{}{
+		"ObjectKind", "Deployment",
+		"ObjectNamespace", "openstack",
+		"ObjectName", "keystone",
+	}))
+
+	// the helper logger is not modified
+	g.Expect(h.GetLogger().GetSink().(*recordingSink).values).To(BeEmpty())
+}
+
+func TestListWithLabelPaginated(t *testing.T) {
+	g := NewWithT(t)
+
+	// fake API server which serves 25 secrets in pages of the requested limit
+	requests := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+"?"+r.URL.Query().Get("labelSelector"))
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		start := 0
+		if c := r.URL.Query().Get("continue"); c != "" {
+			start, _ = strconv.Atoi(c)
+		}
+
+		list := corev1.SecretList{}
+		for i := start; i < 25 && i < start+limit; i++ {
+			list.Items = append(list.Items, corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("secret-%d", i), Namespace: "test"},
+			})
+		}
+		if start+limit < 25 {
+			list.Continue = strconv.Itoa(start + limit)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(list)
+	}))
+	defer srv.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+	reader, err := client.New(&rest.Config{Host: srv.URL}, client.Options{Scheme: scheme.Scheme, Mapper: mapper})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	visited := []string{}
+	err = ListWithLabelPaginated(context.TODO(), reader, &corev1.SecretList{}, "test", map[string]string{"app": "paginated"}, 10,
+		func(obj client.Object) error {
+			visited = append(visited, obj.GetName())
+			return nil
+		})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requests).To(HaveLen(3))
+	g.Expect(requests).To(HaveEach("/api/v1/namespaces/test/secrets?app=paginated"))
+	g.Expect(visited).To(HaveLen(25))
+	g.Expect(visited[0]).To(Equal("secret-0"))
+	g.Expect(visited[24]).To(Equal("secret-24"))
+}
+
+func TestEnsureFinalizerConflict(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}
+	crClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm).Build()
+	h, err := NewHelper(cm, crClient, nil, scheme.Scheme, logr.Discard())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	stale := &corev1.ConfigMap{}
+	g.Expect(crClient.Get(context.TODO(), client.ObjectKeyFromObject(cm), stale)).To(Succeed())
+
+	// another controller adds its finalizer in the meantime
+	current := stale.DeepCopy()
+	current.Finalizers = []string{"other"}
+	g.Expect(crClient.Update(context.TODO(), current)).To(Succeed())
+
+	changed, err := EnsureFinalizer(context.TODO(), h, stale)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+
+	updated := &corev1.ConfigMap{}
+	g.Expect(crClient.Get(context.TODO(), client.ObjectKeyFromObject(cm), updated)).To(Succeed())
+	g.Expect(updated.Finalizers).To(ConsistOf("other", h.GetFinalizer()))
+
+	changed, err = RemoveFinalizer(context.TODO(), h, updated)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(crClient.Get(context.TODO(), client.ObjectKeyFromObject(cm), updated)).To(Succeed())
+	g.Expect(updated.Finalizers).To(ConsistOf("other"))
+}
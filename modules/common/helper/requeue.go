@@ -0,0 +1,37 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// ShortRequeue - default requeue duration when waiting for a resource
+	// which is expected to show up or get ready soon, e.g. a secret or a job
+	ShortRequeue = 5 * time.Second
+	// MediumRequeue - default requeue duration when waiting for an external
+	// condition which usually takes longer to change
+	MediumRequeue = 30 * time.Second
+)
+
+// RequeueAfter - returns a ctrl.Result which requeues the reconcile after d
+func RequeueAfter(d time.Duration) ctrl.Result {
+	return ctrl.Result{RequeueAfter: d}
+}
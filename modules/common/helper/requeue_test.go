@@ -0,0 +1,60 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestRequeueAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		want     ctrl.Result
+	}{
+		{
+			name:     "ShortRequeue",
+			duration: ShortRequeue,
+			want:     ctrl.Result{RequeueAfter: 5 * time.Second},
+		},
+		{
+			name:     "MediumRequeue",
+			duration: MediumRequeue,
+			want:     ctrl.Result{RequeueAfter: 30 * time.Second},
+		},
+		{
+			name:     "Custom duration",
+			duration: time.Minute,
+			want:     ctrl.Result{RequeueAfter: time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			result := RequeueAfter(tt.duration)
+			g.Expect(result).To(Equal(tt.want))
+			g.Expect(result.Requeue).To(BeFalse())
+			g.Expect(result.IsZero()).To(BeFalse())
+		})
+	}
+}
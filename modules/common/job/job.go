@@ -19,14 +19,17 @@ package job
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/pod"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -57,6 +60,88 @@ func NewJob(
 	return j
 }
 
+// WithSidecars adds the containers as native sidecar containers to the Job.
+// Native sidecars are init containers with restartPolicy=Always, which keep
+// running next to the main containers and get stopped once those finished.
+// Sidecars are part of the pod spec and therefore included in the Job hash.
+// A sidecar replaces an existing init container with the same name.
+// Requires Kubernetes 1.28+ with the SidecarContainers feature enabled.
+func (j *Job) WithSidecars(containers ...corev1.Container) *Job {
+	always := corev1.ContainerRestartPolicyAlways
+	podSpec := &j.expectedJob.Spec.Template.Spec
+
+	for _, sidecar := range containers {
+		sidecar.RestartPolicy = &always
+
+		replaced := false
+		for i, c := range podSpec.InitContainers {
+			if c.Name == sidecar.Name {
+				podSpec.InitContainers[i] = sidecar
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			podSpec.InitContainers = append(podSpec.InitContainers, sidecar)
+		}
+	}
+
+	return j
+}
+
+// WithDefaultActiveDeadline sets the ActiveDeadlineSeconds applied to the
+// Job if the caller left job.Spec.ActiveDeadlineSeconds unset. A value set
+// on the Job spec is always preserved. The deadline is not part of the Job
+// hash, so changing it does not trigger a re-run of the Job.
+func (j *Job) WithDefaultActiveDeadline(seconds int64) *Job {
+	j.defaultActiveDeadlineSeconds = &seconds
+	return j
+}
+
+// WithDefaultParallelism sets the Parallelism applied to the Job if the
+// caller left job.Spec.Parallelism unset. A value set on the Job spec, e.g.
+// via WithIndexedCompletion, is always preserved. Like the deadline, the
+// parallelism is not part of the Job hash.
+func (j *Job) WithDefaultParallelism(parallelism int32) *Job {
+	j.defaultParallelism = &parallelism
+	return j
+}
+
+// EnableFailureRestart enables re-running the Job if the existing Job with
+// the same hash failed. By default a failed Job is kept and DoJob reports the
+// failure until the hash of the Job changes. With restart on failure enabled
+// DoJob deletes the Job once it reached its terminal failed state, i.e. the
+// JobFailed condition is set or the backoff limit is reached, and requests a
+// requeue, so that the next DoJob call creates the Job again. While the Job
+// is still retrying failed pods DoJob only requests a requeue.
+func (j *Job) EnableFailureRestart() *Job {
+	j.restartOnFailure = true
+	return j
+}
+
+// WithIndexedCompletion sets the Job to the Indexed completion mode, running
+// completions pods, each getting its index via the JOB_COMPLETION_INDEX env,
+// with up to parallelism pods running at the same time. DoJob only reports
+// the Job as finished when all completions succeeded. The completion settings
+// are not part of the Job hash.
+func (j *Job) WithIndexedCompletion(completions int32, parallelism int32) *Job {
+	indexed := batchv1.IndexedCompletion
+	j.expectedJob.Spec.CompletionMode = &indexed
+	j.expectedJob.Spec.Completions = &completions
+	j.expectedJob.Spec.Parallelism = &parallelism
+	return j
+}
+
+// succeeded - returns true if the number of succeeded pods reached the
+// completions of the Job, which defaults to 1
+func (j *Job) succeeded() bool {
+	completions := int32(1)
+	if j.actualJob.Spec.Completions != nil {
+		completions = *j.actualJob.Spec.Completions
+	}
+	return j.actualJob.Status.Succeeded > 0 && j.actualJob.Status.Succeeded >= completions
+}
+
 // createJob - creates job, reconciles after Xs if object won't exist.
 func (j *Job) createJob(
 	ctx context.Context,
@@ -112,6 +197,20 @@ func (j *Job) defaultTTL() {
 	j.expectedJob.Spec.TTLSecondsAfterFinished = &ttl
 }
 
+// applyDefaults - applies the defaults requested via WithDefaultActiveDeadline
+// and WithDefaultParallelism to the fields the client left unset.
+func (j *Job) applyDefaults() {
+	// if the client set a specific value then we honor it.
+	if j.expectedJob.Spec.ActiveDeadlineSeconds == nil && j.defaultActiveDeadlineSeconds != nil {
+		deadline := *j.defaultActiveDeadlineSeconds
+		j.expectedJob.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	if j.expectedJob.Spec.Parallelism == nil && j.defaultParallelism != nil {
+		parallelism := *j.defaultParallelism
+		j.expectedJob.Spec.Parallelism = &parallelism
+	}
+}
+
 // DoJob - run a job if the hashBefore and hash is different. If the job hash
 // changes while the previous job still running then the first it waits for the
 // previous job to finish then deletes the old job and runs the new one.
@@ -121,6 +220,9 @@ func (j *Job) defaultTTL() {
 // will be deleted after 10 minutes. Set preserve to true if you want to keep
 // the job, or set a specific value to job.Spec.TTLSecondsAfterFinished to
 // define when the Job should be deleted.
+// If a default deadline or parallelism is set via WithDefaultActiveDeadline or
+// WithDefaultParallelism and the job.Spec field is unset, the default is
+// applied to the Job.
 func (j *Job) DoJob(
 	ctx context.Context,
 	h *helper.Helper,
@@ -128,6 +230,8 @@ func (j *Job) DoJob(
 	var ctrlResult ctrl.Result
 	var err error
 
+	j.applyDefaults()
+
 	// We intentionally only include the PodTemplate Spec in the hash of the Job.
 	// PodTemplate metadata is excluded as it can be altered by k8s (labels specifically).
 	// Fields outside of the PodTemplate like TTL do not define what to run,
@@ -223,6 +327,103 @@ func (j *Job) HasReachedLimit() bool {
 	return j.actualJob.Status.Failed > *j.actualJob.Spec.BackoffLimit
 }
 
+// GetFailureReason returns the reason of the JobFailed condition of the job,
+// e.g. batchv1.JobReasonBackoffLimitExceeded or batchv1.JobReasonDeadlineExceeded.
+// Returns an empty string if the job has not failed.
+func (j *Job) GetFailureReason() string {
+	if j.actualJob == nil {
+		return ""
+	}
+	for _, c := range j.actualJob.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return c.Reason
+		}
+	}
+	return ""
+}
+
+// getFailedPodName returns the name of the latest failed pod of the job
+func (j *Job) getFailedPodName(
+	ctx context.Context,
+	h *helper.Helper,
+) (string, error) {
+	failedPod, err := getLatestFailedPod(ctx, h, j.actualJob.Name, j.actualJob.Namespace)
+	if err != nil || failedPod == nil {
+		return "", err
+	}
+
+	return failedPod.Name, nil
+}
+
+// getLatestFailedPod returns the latest failed pod of the job jobName, or nil
+// if the job has no failed pod
+func getLatestFailedPod(
+	ctx context.Context,
+	h *helper.Helper,
+	jobName string,
+	namespace string,
+) (*corev1.Pod, error) {
+	podList, err := pod.GetPodListWithLabel(
+		ctx, h, namespace, map[string]string{batchv1.JobNameLabel: jobName})
+	if err != nil {
+		return nil, err
+	}
+
+	var failedPod *corev1.Pod
+	for idx, p := range podList.Items {
+		if p.Status.Phase != corev1.PodFailed {
+			continue
+		}
+		if failedPod == nil || failedPod.CreationTimestamp.Before(&p.CreationTimestamp) {
+			failedPod = &podList.Items[idx]
+		}
+	}
+
+	return failedPod, nil
+}
+
+// GetJobLogs returns the last tailLines lines of the container logs of the
+// latest failed pod of the job jobName in namespace. If the pod has multiple
+// containers the logs of each container are prefixed with the container name.
+// A tailLines <= 0 returns the full logs. If the job has no failed pod (yet)
+// an empty string is returned.
+func GetJobLogs(
+	ctx context.Context,
+	h *helper.Helper,
+	jobName string,
+	namespace string,
+	tailLines int64,
+) (string, error) {
+	failedPod, err := getLatestFailedPod(ctx, h, jobName, namespace)
+	if err != nil {
+		return "", err
+	}
+	if failedPod == nil {
+		return "", nil
+	}
+
+	logs := []string{}
+	for _, container := range failedPod.Spec.Containers {
+		opts := &corev1.PodLogOptions{Container: container.Name}
+		if tailLines > 0 {
+			opts.TailLines = &tailLines
+		}
+
+		raw, err := h.GetKClient().CoreV1().Pods(namespace).GetLogs(failedPod.Name, opts).DoRaw(ctx)
+		if err != nil {
+			return "", fmt.Errorf("error getting logs of container %s of pod %s: %w", container.Name, failedPod.Name, err)
+		}
+
+		if len(failedPod.Spec.Containers) > 1 {
+			logs = append(logs, fmt.Sprintf("==> %s <==\n%s", container.Name, raw))
+		} else {
+			logs = append(logs, string(raw))
+		}
+	}
+
+	return strings.Join(logs, "\n"), nil
+}
+
 // DeleteJob deletes the batchv1.Job if exists. It is not an error to call
 // this on an already deleted job.
 func DeleteJob(
@@ -259,7 +460,7 @@ func (j *Job) waitOnJob(
 		}
 		h.GetLogger().Info("Job Status Active... requeuing")
 		return ctrl.Result{RequeueAfter: j.timeout}, nil
-	} else if j.actualJob.Status.Succeeded > 0 {
+	} else if j.succeeded() {
 		if existingJobHash != j.hash {
 			h.GetLogger().Info(
 				"The hash of the job changed but the previously succeeded job still exists. " +
@@ -283,12 +484,44 @@ func (j *Job) waitOnJob(
 			}
 			return ctrl.Result{RequeueAfter: j.timeout}, nil
 		}
+		if j.restartOnFailure {
+			// a job within its backoff window retries the failed pod itself,
+			// deleting it would reset the retries and bypass the backoff limit
+			if j.GetFailureReason() == "" && !j.HasReachedLimit() {
+				h.GetLogger().Info("Job attempt failed, waiting for the job to retry... requeuing")
+				return ctrl.Result{RequeueAfter: j.timeout}, nil
+			}
+			h.GetLogger().Info(
+				"The previous job with the same hash failed and restart on failure is enabled. " +
+					"Deleting old job and requeueing.")
+			err := DeleteJob(ctx, h, j.actualJob.Name, j.actualJob.Namespace)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: j.timeout}, nil
+		}
 		h.GetLogger().Info("Job Status Failed")
+		reason := j.GetFailureReason()
+		podName, err := j.getFailedPodName(ctx, h)
+		if err != nil {
+			// the pod name is only a hint, so just log the error
+			h.GetLogger().Info(fmt.Sprintf("Failed to get the failed pod of job %s: %s", j.actualJob.Name, err))
+		}
+
 		errMsg := fmt.Sprintf("Job Attempt #%d Failed. Check job logs", j.GetTotalFailedAttempts())
-		if j.HasReachedLimit() {
+		if j.HasReachedLimit() || reason == batchv1.JobReasonBackoffLimitExceeded {
 			errMsg = "Job has reached the specified backoff limit. Check job logs"
+		} else if reason == batchv1.JobReasonDeadlineExceeded {
+			errMsg = "Job has reached the specified active deadline. Check job logs"
+		}
+		if podName != "" {
+			errMsg = fmt.Sprintf("%s of pod %s", errMsg, podName)
+		}
+		return ctrl.Result{}, &FailedError{
+			Reason:  reason,
+			PodName: podName,
+			err:     k8s_errors.NewInternalError(errors.New(errMsg)),
 		}
-		return ctrl.Result{}, k8s_errors.NewInternalError(errors.New(errMsg))
 	} else {
 		if existingJobHash != j.hash {
 			h.GetLogger().Info(
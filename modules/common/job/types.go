@@ -37,4 +37,33 @@ type Job struct {
 	beforeHash  string
 	hash        string
 	changed     bool
+	// defaultActiveDeadlineSeconds - applied to the Job if its spec does
+	// not set ActiveDeadlineSeconds
+	defaultActiveDeadlineSeconds *int64
+	// defaultParallelism - applied to the Job if its spec does not set
+	// Parallelism
+	defaultParallelism *int32
+	// restartOnFailure - re-run the Job if it failed with the same hash
+	restartOnFailure bool
+}
+
+// FailedError - error returned by DoJob if the job failed. It wraps the
+// k8s InternalError describing the failure.
+type FailedError struct {
+	// Reason - reason of the JobFailed condition, e.g. batchv1.JobReasonBackoffLimitExceeded
+	// or batchv1.JobReasonDeadlineExceeded. Empty if the job has no JobFailed condition (yet).
+	Reason string
+	// PodName - name of the latest failed pod of the job, if any
+	PodName string
+	err     error
+}
+
+// Error -
+func (e *FailedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap -
+func (e *FailedError) Unwrap() error {
+	return e.err
 }
@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"net"
 	"sort"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 // SortIPs - Get network-attachment-definition with name in namespace
@@ -44,3 +46,44 @@ func SortIPs(
 
 	return sortedIPs
 }
+
+// SortIPsByFamily - splits ips into sorted lists of IPv4 and IPv6 addresses.
+// Invalid IPs are ignored.
+func SortIPsByFamily(
+	ips []string,
+) (ipv4 []string, ipv6 []string) {
+	ipv4 = []string{}
+	ipv6 = []string{}
+
+	for _, ip := range ips {
+		netIP := net.ParseIP(ip)
+		if netIP == nil {
+			continue
+		}
+		if netIP.To4() != nil {
+			ipv4 = append(ipv4, ip)
+		} else {
+			ipv6 = append(ipv6, ip)
+		}
+	}
+
+	return SortIPs(ipv4), SortIPs(ipv6)
+}
+
+// StableIPFamilies - returns the IP families of ips in a stable order, IPv4
+// first, to be used for the IPFamilies of a dual-stack ServiceSpec.
+func StableIPFamilies(
+	ips []string,
+) []corev1.IPFamily {
+	ipv4, ipv6 := SortIPsByFamily(ips)
+
+	families := []corev1.IPFamily{}
+	if len(ipv4) > 0 {
+		families = append(families, corev1.IPv4Protocol)
+	}
+	if len(ipv6) > 0 {
+		families = append(families, corev1.IPv6Protocol)
+	}
+
+	return families
+}
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestSortIPs(t *testing.T) {
@@ -92,3 +93,68 @@ func TestSortIPs(t *testing.T) {
 		})
 	}
 }
+
+func TestSortIPsByFamily(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		ips      []string
+		wantIPv4 []string
+		wantIPv6 []string
+		families []corev1.IPFamily
+	}{
+		{
+			name:     "empty ip list",
+			ips:      []string{},
+			wantIPv4: []string{},
+			wantIPv6: []string{},
+			families: []corev1.IPFamily{},
+		},
+		{
+			name:     "IPv4 only",
+			ips:      []string{"2.2.2.2", "1.1.1.1"},
+			wantIPv4: []string{"1.1.1.1", "2.2.2.2"},
+			wantIPv6: []string{},
+			families: []corev1.IPFamily{corev1.IPv4Protocol},
+		},
+		{
+			name:     "IPv6 only",
+			ips:      []string{"fd00:bbbb::2", "fd00:bbbb::1"},
+			wantIPv4: []string{},
+			wantIPv6: []string{"fd00:bbbb::1", "fd00:bbbb::2"},
+			families: []corev1.IPFamily{corev1.IPv6Protocol},
+		},
+		{
+			name:     "IPv6 first mixed list",
+			ips:      []string{"fd00:bbbb::2", "2.2.2.2", "fd00:aaaa::1", "1.1.1.1"},
+			wantIPv4: []string{"1.1.1.1", "2.2.2.2"},
+			wantIPv6: []string{"fd00:aaaa::1", "fd00:bbbb::2"},
+			families: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+		},
+		{
+			name:     "IPv4 first mixed list",
+			ips:      []string{"1.1.1.1", "fd00:aaaa::1"},
+			wantIPv4: []string{"1.1.1.1"},
+			wantIPv6: []string{"fd00:aaaa::1"},
+			families: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+		},
+		{
+			name:     "invalid IPs are ignored",
+			ips:      []string{"foo", "1.1.1.1", "fd00:aaaa::1::1"},
+			wantIPv4: []string{"1.1.1.1"},
+			wantIPv6: []string{},
+			families: []corev1.IPFamily{corev1.IPv4Protocol},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ipv4, ipv6 := SortIPsByFamily(tt.ips)
+			g.Expect(ipv4).To(Equal(tt.wantIPv4))
+			g.Expect(ipv6).To(Equal(tt.wantIPv6))
+			g.Expect(StableIPFamilies(tt.ips)).To(Equal(tt.families))
+		})
+	}
+}
@@ -22,10 +22,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"sort"
+	"strings"
 
 	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/pod"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/jsonpath"
 )
@@ -50,6 +54,30 @@ func GetNADWithName(
 	return nad, nil
 }
 
+// VerifyNetworkAttachments - verifies that the requested
+// network-attachment-definitions exist in the namespace and returns the names
+// of the missing ones, e.g. to be used in the NetworkAttachmentsReadyWaitingMessage.
+func VerifyNetworkAttachments(
+	ctx context.Context,
+	h *helper.Helper,
+	namespace string,
+	requested []string,
+) ([]string, error) {
+	missing := []string{}
+	for _, name := range requested {
+		_, err := GetNADWithName(ctx, h, name, namespace)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				missing = append(missing, name)
+				continue
+			}
+			return missing, err
+		}
+	}
+
+	return missing, nil
+}
+
 // CreateNetworksAnnotation returns pod annotation for network-attachment-definition list
 // e.g. k8s.v1.cni.cncf.io/networks: '[{"name": "internalapi", "namespace": "openstack"},{"name": "storage", "namespace": "openstack"}]'
 // NOTE: Deprecated, use EnsureNetworksAnnotation
@@ -99,6 +127,46 @@ func GetNetworkStatusFromAnnotation(annotations map[string]string) ([]networkv1.
 	return netStatus, nil
 }
 
+// GetIPsForNetwork returns the IPs of the network with networkName from the
+// NetworkStatus pod annotation. The networkName can be passed as "internalapi"
+// or in the namespaced form "openstack/internalapi". If the network is not
+// present, an empty list is returned.
+func GetIPsForNetwork(annotations map[string]string, networkName string) ([]string, error) {
+	ips := []string{}
+
+	netsStatus, err := GetNetworkStatusFromAnnotation(annotations)
+	if err != nil {
+		return ips, err
+	}
+
+	for _, netStat := range netsStatus {
+		if networkNameMatches(netStat.Name, networkName) {
+			ips = append(ips, netStat.IPs...)
+		}
+	}
+
+	return ips, nil
+}
+
+// networkNameMatches - returns true if the network status name matches the
+// network name. The namespace is only compared if both names have one.
+func networkNameMatches(statusName string, networkName string) bool {
+	statusNamespace, statusNet, statusHasNamespace := strings.Cut(statusName, "/")
+	namespace, netName, hasNamespace := strings.Cut(networkName, "/")
+	if !statusHasNamespace {
+		statusNet = statusNamespace
+	}
+	if !hasNamespace {
+		netName = namespace
+	}
+
+	if statusHasNamespace && hasNamespace {
+		return statusName == networkName
+	}
+
+	return statusNet == netName
+}
+
 // VerifyNetworkStatusFromAnnotation - verifies if NetworkStatus annotation for the pods of a deployment,
 // pods identified via the service label selector, matches the passed in network attachments and the number of
 // per network IPs the ready count of the deployment. Return true if count matches with the list of IPs per network.
@@ -141,58 +209,118 @@ func VerifyNetworkStatusFromAnnotation(
 	return networkReady, networkAttachmentStatus, nil
 }
 
+// VerifyNetworkStatusReady - verifies that every pod in the namespace matching
+// podLabels has at least one IP on every required network in its NetworkStatus
+// annotation. The required networks can be passed as "internalapi" or in the
+// namespaced form "openstack/internalapi". Returns true if all pods are
+// attached to all required networks, otherwise the unsatisfied networks get
+// returned as "<pod name>/<network>", sorted by pod name. If there are no
+// matching pods it returns true, the caller has to verify the pod count.
+func VerifyNetworkStatusReady(
+	ctx context.Context,
+	h *helper.Helper,
+	namespace string,
+	podLabels map[string]string,
+	requiredNetworks []string,
+) (bool, []string, error) {
+	unsatisfied := []string{}
+	if len(requiredNetworks) == 0 {
+		return true, unsatisfied, nil
+	}
+
+	podList, err := pod.GetPodListWithLabel(ctx, h, namespace, podLabels)
+	if err != nil {
+		return false, unsatisfied, err
+	}
+	sort.Slice(podList.Items, func(i, j int) bool {
+		return podList.Items[i].Name < podList.Items[j].Name
+	})
+
+	for _, p := range podList.Items {
+		netsStatus, err := GetNetworkStatusFromAnnotation(p.Annotations)
+		if err != nil {
+			return false, unsatisfied, fmt.Errorf("pod %s: %w", p.Name, err)
+		}
+
+		for _, network := range requiredNetworks {
+			hasIP := false
+			for _, netStat := range netsStatus {
+				if networkNameMatches(netStat.Name, network) && len(netStat.IPs) > 0 {
+					hasIP = true
+					break
+				}
+			}
+			if !hasIP {
+				unsatisfied = append(unsatisfied, p.Name+"/"+network)
+			}
+		}
+	}
+
+	return len(unsatisfied) == 0, unsatisfied, nil
+}
+
 // EnsureNetworksAnnotation returns pod annotation for network-attachment-definition list
 // e.g. k8s.v1.cni.cncf.io/networks: '[{"name": "internalapi", "namespace": "openstack"},{"name": "storage", "namespace": "openstack"}]'
 // If `ipam.gateway` is defined in the NAD, the annotation will contain the `default-route` for that network:
 // e.g. k8s.v1.cni.cncf.io/networks: '[{"name":"internalapi","namespace":"openstack","interface":"internalapi","default-route":["10.1.2.200"]}]'
+// For dual-stack NADs the IPv6 gateway of the `::/0` route in `ipam.routes` is
+// added as well.
 func EnsureNetworksAnnotation(
 	nadList []networkv1.NetworkAttachmentDefinition,
 ) (map[string]string, error) {
+	return EnsureNetworksAnnotationWithOptions(nadList, nil)
+}
+
+// NetworkAttachOptions - per network options for EnsureNetworksAnnotationWithOptions
+type NetworkAttachOptions struct {
+	// Interface - name of the interface in the pod, if not set the name is
+	// derived from the NAD name using GetNetworkIFName
+	Interface string
+	// MTU - if set, the MTU is passed to the CNI plugin via the `cni-args`
+	// of the network. The CNI plugin of the NAD must support it.
+	MTU int
+	// DefaultRoute - if set, overrides the `default-route` gateways derived
+	// from the ipam config of the NAD
+	DefaultRoute []net.IP
+}
+
+// EnsureNetworksAnnotationWithOptions - like EnsureNetworksAnnotation, but
+// allows to customize the interface name, MTU and default route per network.
+// The opts map is keyed by the NAD name, networks without an entry use the
+// defaults of EnsureNetworksAnnotation.
+// e.g. k8s.v1.cni.cncf.io/networks: '[{"name":"internalapi","namespace":"openstack","interface":"eth1","cni-args":{"mtu":9000}}]'
+func EnsureNetworksAnnotationWithOptions(
+	nadList []networkv1.NetworkAttachmentDefinition,
+	opts map[string]NetworkAttachOptions,
+) (map[string]string, error) {
 
 	annotationString := map[string]string{}
 	netAnnotations := []networkv1.NetworkSelectionElement{}
 	for _, nad := range nadList {
-		gateway := ""
-
-		var data interface{}
-		if err := json.Unmarshal([]byte(nad.Spec.Config), &data); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal JSON data: %w", err)
-		}
-
-		// use jsonpath to parse the cni config
-		jp := jsonpath.New(nad.Name)
-		jp.AllowMissingKeys(true) // Allow missing keys, for when no gateway configured
-
-		// Parse the JSONPath template, for now just `ipam.gateway`
-		err := jp.Parse(`{.ipam.gateway}`)
+		gatewayReq, err := getDefaultRouteGateways(nad)
 		if err != nil {
-			return annotationString, fmt.Errorf("parse template error: %w", err)
+			return nil, err
 		}
 
-		buf := new(bytes.Buffer)
-		// get the gateway from the config
-		err = jp.Execute(buf, data)
-		if err != nil {
-			return annotationString, fmt.Errorf("parse execute template against nad %+v error: %w", nad.Spec.Config, err)
+		netAnnotation := networkv1.NetworkSelectionElement{
+			Name:             nad.Name,
+			Namespace:        nad.Namespace,
+			InterfaceRequest: GetNetworkIFName(nad.Name),
+			GatewayRequest:   gatewayReq,
 		}
-
-		gateway = buf.String()
-
-		gatewayReq := []net.IP{}
-		if gateway != "" {
-			gatewayReq = append(gatewayReq, net.ParseIP(gateway))
-
+		if opt, ok := opts[nad.Name]; ok {
+			if opt.Interface != "" {
+				netAnnotation.InterfaceRequest = opt.Interface
+			}
+			if opt.MTU > 0 {
+				netAnnotation.CNIArgs = &map[string]interface{}{"mtu": opt.MTU}
+			}
+			if opt.DefaultRoute != nil {
+				netAnnotation.GatewayRequest = opt.DefaultRoute
+			}
 		}
 
-		netAnnotations = append(
-			netAnnotations,
-			networkv1.NetworkSelectionElement{
-				Name:             nad.Name,
-				Namespace:        nad.Namespace,
-				InterfaceRequest: GetNetworkIFName(nad.Name),
-				GatewayRequest:   gatewayReq,
-			},
-		)
+		netAnnotations = append(netAnnotations, netAnnotation)
 	}
 
 	networks, err := json.Marshal(netAnnotations)
@@ -205,6 +333,66 @@ func EnsureNetworksAnnotation(
 	return annotationString, nil
 }
 
+// getDefaultRouteGateways - returns the gateways for the default routes of the
+// NAD, which are `ipam.gateway` and, for dual-stack NADs, the IPv6 gateway of
+// the `::/0` route in `ipam.routes` if `ipam.gateway` is not an IPv6 address.
+// Routes which can not be parsed are ignored.
+func getDefaultRouteGateways(nad networkv1.NetworkAttachmentDefinition) ([]net.IP, error) {
+	gateways := []net.IP{}
+	if nad.Spec.Config == "" {
+		return gateways, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(nad.Spec.Config), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON data: %w", err)
+	}
+
+	gateway, err := executeJSONPath(nad.Name, data, `{.ipam.gateway}`)
+	if err != nil {
+		return nil, fmt.Errorf("parse execute template against nad %+v error: %w", nad.Spec.Config, err)
+	}
+	hasIPv6 := false
+	if ip := net.ParseIP(gateway); ip != nil {
+		gateways = append(gateways, ip)
+		hasIPv6 = ip.To4() == nil
+	}
+	if hasIPv6 {
+		return gateways, nil
+	}
+
+	routeGateways, err := executeJSONPath(nad.Name, data, `{range .ipam.routes[?(@.dst=="::/0")]}{.gw}{" "}{end}`)
+	if err != nil {
+		// unexpected routes format, keep the gateway from `ipam.gateway`
+		return gateways, nil
+	}
+	for _, gw := range strings.Fields(routeGateways) {
+		if ip := net.ParseIP(gw); ip != nil && ip.To4() == nil {
+			gateways = append(gateways, ip)
+			break
+		}
+	}
+
+	return gateways, nil
+}
+
+// executeJSONPath - executes the jsonpath template against the parsed config
+// data, missing keys result in an empty string
+func executeJSONPath(name string, data interface{}, template string) (string, error) {
+	jp := jsonpath.New(name)
+	jp.AllowMissingKeys(true)
+	if err := jp.Parse(template); err != nil {
+		return "", fmt.Errorf("parse template error: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := jp.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
 // GetJSONPathFromConfig - returns the result of the jsonPath as string
 // from the NetworkAttachmentDefinition config.
 // if the NAD has no config, an empty string is returned.
@@ -238,3 +426,22 @@ func GetJSONPathFromConfig(netAtt networkv1.NetworkAttachmentDefinition, path st
 
 	return buf.String(), nil
 }
+
+// NADsHash - returns a hash over the parsed config of the NADs in nadList.
+// The hash does not depend on the order of the list, or the formatting of the
+// config, and can be added to the pod template hash to roll out pods when the
+// config of a NAD changes.
+func NADsHash(nadList []networkv1.NetworkAttachmentDefinition) (string, error) {
+	configs := map[string]interface{}{}
+	for _, nad := range nadList {
+		var data interface{}
+		if nad.Spec.Config != "" {
+			if err := json.Unmarshal([]byte(nad.Spec.Config), &data); err != nil {
+				return "", fmt.Errorf("failed to unmarshal JSON data of nad %s/%s: %w", nad.Namespace, nad.Name, err)
+			}
+		}
+		configs[types.NamespacedName{Name: nad.Name, Namespace: nad.Namespace}.String()] = data
+	}
+
+	return util.ObjectHash(configs)
+}
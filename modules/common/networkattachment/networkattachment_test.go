@@ -17,6 +17,8 @@ limitations under the License.
 package networkattachment
 
 import (
+	"fmt"
+	"net"
 	"testing"
 
 	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
@@ -141,6 +143,74 @@ func TestGetNetworkStatusFromAnnotation(t *testing.T) {
 
 }
 
+func TestGetIPsForNetwork(t *testing.T) {
+
+	annotations := map[string]string{
+		"k8s.v1.cni.cncf.io/network-status": "[{\n    \"name\": \"openshift-sdn\",\n    \"interface\": \"eth0\",\n    \"ips\": [\n        \"10.130.0.16\"\n    ],\n    \"default\": true,\n    \"dns\": {}\n},{\n    \"name\": \"openstack/internalapi\",\n    \"interface\": \"internalapi\",\n    \"ips\": [\n        \"172.17.0.226\",\n        \"fd00:bbbb::226\"\n    ],\n    \"mac\": \"a2:ef:bb:ae:65:45\",\n    \"dns\": {}\n},{\n    \"name\": \"storage\",\n    \"interface\": \"storage\",\n    \"ips\": [\n        \"172.18.0.226\"\n    ],\n    \"mac\": \"a2:ef:bb:ae:65:46\",\n    \"dns\": {}\n}]",
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		network     string
+		want        []string
+	}{
+		{
+			name:        "Empty annotation",
+			annotations: map[string]string{},
+			network:     "internalapi",
+			want:        []string{},
+		},
+		{
+			name:        "Network name without namespace",
+			annotations: annotations,
+			network:     "internalapi",
+			want:        []string{"172.17.0.226", "fd00:bbbb::226"},
+		},
+		{
+			name:        "Network name with namespace",
+			annotations: annotations,
+			network:     "openstack/internalapi",
+			want:        []string{"172.17.0.226", "fd00:bbbb::226"},
+		},
+		{
+			name:        "Network name with other namespace",
+			annotations: annotations,
+			network:     "other/internalapi",
+			want:        []string{},
+		},
+		{
+			name:        "Network status without namespace",
+			annotations: annotations,
+			network:     "openstack/storage",
+			want:        []string{"172.18.0.226"},
+		},
+		{
+			name:        "Network not present",
+			annotations: annotations,
+			network:     "tenant",
+			want:        []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ips, err := GetIPsForNetwork(tt.annotations, tt.network)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ips).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("Invalid annotation", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := GetIPsForNetwork(map[string]string{"k8s.v1.cni.cncf.io/network-status": "{"}, "internalapi")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
 func TestGetNetworkIFName(t *testing.T) {
 
 	tests := []struct {
@@ -272,6 +342,86 @@ func TestEnsureNetworksAnnotation(t *testing.T) {
     "gateway": "172.17.0.1"
   }
 }
+`,
+					},
+				},
+			},
+			want: map[string]string{networkv1.NetworkAttachmentAnnot: "[{\"name\":\"one\",\"namespace\":\"foo\",\"interface\":\"one\",\"default-route\":[\"172.17.0.1\"]}]"},
+		},
+		{
+			name: "Gateways defined in ranges are not used as default-route",
+			nadList: []networkv1.NetworkAttachmentDefinition{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "one", Namespace: "foo"},
+					Spec: networkv1.NetworkAttachmentDefinitionSpec{
+						Config: `
+{
+  "cniVersion": "0.3.1",
+  "name": "internalapi",
+  "type": "macvlan",
+  "master": "internalapi",
+  "ipam": {
+    "type": "host-local",
+    "ranges": [
+      [{"subnet": "172.17.0.0/24", "gateway": "172.17.0.1"}],
+      [{"subnet": "fd00:bbbb::/64", "gateway": "fd00:bbbb::1"}]
+    ]
+  }
+}
+`,
+					},
+				},
+			},
+			want: map[string]string{networkv1.NetworkAttachmentAnnot: "[{\"name\":\"one\",\"namespace\":\"foo\",\"interface\":\"one\"}]"},
+		},
+		{
+			name: "With dual-stack gateways defined in routes",
+			nadList: []networkv1.NetworkAttachmentDefinition{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "one", Namespace: "foo"},
+					Spec: networkv1.NetworkAttachmentDefinitionSpec{
+						Config: `
+{
+  "cniVersion": "0.3.1",
+  "name": "internalapi",
+  "type": "macvlan",
+  "master": "internalapi",
+  "ipam": {
+    "type": "whereabouts",
+    "range": "172.17.0.0/24",
+    "gateway": "172.17.0.1",
+    "routes": [
+      {"dst": "0.0.0.0/0", "gw": "172.17.0.1"},
+      {"dst": "::/0", "gw": "fd00:bbbb::1"},
+      {"dst": "172.20.0.0/24", "gw": "172.17.0.254"}
+    ]
+  }
+}
+`,
+					},
+				},
+			},
+			want: map[string]string{networkv1.NetworkAttachmentAnnot: "[{\"name\":\"one\",\"namespace\":\"foo\",\"interface\":\"one\",\"default-route\":[\"172.17.0.1\",\"fd00:bbbb::1\"]}]"},
+		},
+		{
+			name: "With unexpected routes format",
+			nadList: []networkv1.NetworkAttachmentDefinition{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "one", Namespace: "foo"},
+					Spec: networkv1.NetworkAttachmentDefinitionSpec{
+						Config: `
+{
+  "cniVersion": "0.3.1",
+  "name": "internalapi",
+  "type": "macvlan",
+  "master": "internalapi",
+  "ipam": {
+    "type": "whereabouts",
+    "range": "172.17.0.0/24",
+    "gateway": "172.17.0.1",
+    "routes": "::/0"
+  }
+}
 `,
 					},
 				},
@@ -292,6 +442,72 @@ func TestEnsureNetworksAnnotation(t *testing.T) {
 	}
 }
 
+func TestEnsureNetworksAnnotationWithOptions(t *testing.T) {
+	nadList := []networkv1.NetworkAttachmentDefinition{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "internalapi-network", Namespace: "foo"},
+			Spec: networkv1.NetworkAttachmentDefinitionSpec{
+				Config: `
+{
+  "cniVersion": "0.3.1",
+  "name": "internalapi",
+  "type": "macvlan",
+  "master": "internalapi",
+  "ipam": {
+    "type": "whereabouts",
+    "range": "172.17.0.0/24",
+    "gateway": "172.17.0.1"
+  }
+}
+`,
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts map[string]NetworkAttachOptions
+		want map[string]string
+	}{
+		{
+			name: "No options",
+			opts: nil,
+			want: map[string]string{networkv1.NetworkAttachmentAnnot: "[{\"name\":\"internalapi-network\",\"namespace\":\"foo\",\"interface\":\"internalapi-net\",\"default-route\":[\"172.17.0.1\"]}]"},
+		},
+		{
+			name: "Options for another network",
+			opts: map[string]NetworkAttachOptions{
+				"other": {Interface: "eth1", MTU: 9000},
+			},
+			want: map[string]string{networkv1.NetworkAttachmentAnnot: "[{\"name\":\"internalapi-network\",\"namespace\":\"foo\",\"interface\":\"internalapi-net\",\"default-route\":[\"172.17.0.1\"]}]"},
+		},
+		{
+			name: "Custom interface and MTU",
+			opts: map[string]NetworkAttachOptions{
+				"internalapi-network": {Interface: "eth1", MTU: 9000},
+			},
+			want: map[string]string{networkv1.NetworkAttachmentAnnot: "[{\"name\":\"internalapi-network\",\"namespace\":\"foo\",\"interface\":\"eth1\",\"cni-args\":{\"mtu\":9000},\"default-route\":[\"172.17.0.1\"]}]"},
+		},
+		{
+			name: "Custom default route",
+			opts: map[string]NetworkAttachOptions{
+				"internalapi-network": {DefaultRoute: []net.IP{net.ParseIP("172.17.0.254")}},
+			},
+			want: map[string]string{networkv1.NetworkAttachmentAnnot: "[{\"name\":\"internalapi-network\",\"namespace\":\"foo\",\"interface\":\"internalapi-net\",\"default-route\":[\"172.17.0.254\"]}]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			networkAnnotation, err := EnsureNetworksAnnotationWithOptions(nadList, tt.opts)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(networkAnnotation).To(BeEquivalentTo(tt.want))
+		})
+	}
+}
+
 func TestGetJSONPathFromConfig(t *testing.T) {
 
 	tests := []struct {
@@ -367,3 +583,78 @@ func TestGetJSONPathFromConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestNADsHash(t *testing.T) {
+	getNAD := func(name string, ipRange string) networkv1.NetworkAttachmentDefinition {
+		return networkv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "foo"},
+			Spec: networkv1.NetworkAttachmentDefinitionSpec{
+				Config: fmt.Sprintf(`
+			{
+			  "cniVersion": "0.3.1",
+			  "name": "%s",
+			  "type": "macvlan",
+			  "master": "%s",
+			  "ipam": {
+			    "type": "whereabouts",
+			    "range": "%s"
+			  }
+			}
+			`, name, name, ipRange),
+			},
+		}
+	}
+
+	g := NewWithT(t)
+
+	nads := []networkv1.NetworkAttachmentDefinition{
+		getNAD("internalapi", "172.17.0.0/24"),
+		getNAD("storage", "172.18.0.0/24"),
+	}
+	hash, err := NADsHash(nads)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(hash).NotTo(BeEmpty())
+
+	t.Run("Same NADs in different order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		reordered := []networkv1.NetworkAttachmentDefinition{
+			getNAD("storage", "172.18.0.0/24"),
+			getNAD("internalapi", "172.17.0.0/24"),
+		}
+		newHash, err := NADsHash(reordered)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(newHash).To(Equal(hash))
+	})
+
+	t.Run("Same config with different formatting", func(t *testing.T) {
+		g := NewWithT(t)
+
+		compact := getNAD("internalapi", "172.17.0.0/24")
+		compact.Spec.Config = `{"ipam":{"range":"172.17.0.0/24","type":"whereabouts"},"master":"internalapi","name":"internalapi","type":"macvlan","cniVersion":"0.3.1"}`
+		newHash, err := NADsHash([]networkv1.NetworkAttachmentDefinition{compact, getNAD("storage", "172.18.0.0/24")})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(newHash).To(Equal(hash))
+	})
+
+	t.Run("Config change", func(t *testing.T) {
+		g := NewWithT(t)
+
+		changed := []networkv1.NetworkAttachmentDefinition{
+			getNAD("internalapi", "172.17.1.0/24"),
+			getNAD("storage", "172.18.0.0/24"),
+		}
+		newHash, err := NADsHash(changed)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(newHash).NotTo(Equal(hash))
+	})
+
+	t.Run("Invalid config", func(t *testing.T) {
+		g := NewWithT(t)
+
+		invalid := getNAD("internalapi", "172.17.0.0/24")
+		invalid.Spec.Config = "{"
+		_, err := NADsHash([]networkv1.NetworkAttachmentDefinition{invalid})
+		g.Expect(err).To(HaveOccurred())
+	})
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	policyv1 "k8s.io/api/policy/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// NewPDB returns an initialized PDB.
+func NewPDB(
+	pdb *policyv1.PodDisruptionBudget,
+	timeout time.Duration,
+) *PDB {
+	return &PDB{
+		pdb:     pdb,
+		timeout: timeout,
+	}
+}
+
+// CreateOrPatch - creates or patches a PodDisruptionBudget, reconciles after Xs if object won't exist.
+func (p *PDB) CreateOrPatch(
+	ctx context.Context,
+	h *helper.Helper,
+) (ctrl.Result, error) {
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      p.pdb.Name,
+			Namespace: p.pdb.Namespace,
+		},
+	}
+
+	op, err := controllerutil.CreateOrPatch(ctx, h.GetClient(), pdb, func() error {
+		pdb.Labels = util.MergeStringMaps(pdb.Labels, p.pdb.Labels)
+		pdb.Annotations = util.MergeStringMaps(pdb.Annotations, p.pdb.Annotations)
+		pdb.Spec = p.pdb.Spec
+
+		err := controllerutil.SetControllerReference(h.GetBeforeObject(), pdb, h.GetScheme())
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			h.GetLogger().Info(fmt.Sprintf("PodDisruptionBudget %s not found, reconcile in %s", pdb.Name, p.timeout))
+			return ctrl.Result{RequeueAfter: p.timeout}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if op != controllerutil.OperationResultNone {
+		h.GetLogger().Info(fmt.Sprintf("PodDisruptionBudget %s - %s", pdb.Name, op))
+	}
+
+	// update the pdb object of the PDB type
+	p.pdb = pdb
+
+	return ctrl.Result{}, nil
+}
+
+// CreateOrPatchAndWaitReady - creates or patches a PodDisruptionBudget and
+// reconciles after Xs if the PodDisruptionBudget is not ready yet, see IsReady.
+func (p *PDB) CreateOrPatchAndWaitReady(
+	ctx context.Context,
+	h *helper.Helper,
+) (ctrl.Result, error) {
+	result, err := p.CreateOrPatch(ctx, h)
+	if err != nil || result != (ctrl.Result{}) {
+		return result, err
+	}
+
+	if !p.IsReady() {
+		h.GetLogger().Info(fmt.Sprintf("PodDisruptionBudget %s not ready, reconcile in %s", p.pdb.Name, p.timeout))
+		return ctrl.Result{RequeueAfter: p.timeout}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// Delete - delete a PodDisruptionBudget.
+func (p *PDB) Delete(
+	ctx context.Context,
+	h *helper.Helper,
+) error {
+	err := h.GetClient().Delete(ctx, p.pdb)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("Error deleting PodDisruptionBudget %s: %w", p.pdb.Name, err)
+	}
+
+	return nil
+}
+
+// GetPDB - get the PodDisruptionBudget object.
+func (p *PDB) GetPDB() policyv1.PodDisruptionBudget {
+	return *p.pdb
+}
+
+// IsReady - returns true if the disruption controller processed the current
+// generation of the PodDisruptionBudget, found the pods it selects and was
+// able to compute the allowed disruptions.
+// NOTE: The PDB is also reported ready if none of the selected pods is
+// healthy, e.g. DisruptionsAllowed is 0 and the DisruptionAllowed condition
+// has the InsufficientPods reason. This is a valid state of the PDB which only
+// reflects the state of the pods, not an error of the PDB itself. Only the
+// SyncFailed reason, which is set if the disruptions could not be computed,
+// is considered to be an error.
+func (p *PDB) IsReady() bool {
+	if p.pdb.Status.ObservedGeneration != p.pdb.Generation ||
+		p.pdb.Status.ExpectedPods <= 0 {
+		return false
+	}
+
+	cond := meta.FindStatusCondition(p.pdb.Status.Conditions, policyv1.DisruptionAllowedCondition)
+	if cond != nil && cond.Reason == policyv1.SyncFailedReason {
+		return false
+	}
+
+	return true
+}
+
+// DefaultForReplicas - returns a PodDisruptionBudget sized for the replica count.
+// minAvailable is set to replicas-1 so that only a single pod can be disrupted
+// at a time, which keeps quorum based services available during e.g. node drains.
+// For replicas <= 1 no PDB is returned (nil), as a PDB would either block any
+// voluntary disruption or not protect anything.
+func DefaultForReplicas(
+	name string,
+	namespace string,
+	replicas int32,
+	selector map[string]string,
+) *policyv1.PodDisruptionBudget {
+	if replicas <= 1 {
+		return nil
+	}
+
+	minAvailable := intstr.FromInt32(replicas - 1)
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selector,
+			},
+		},
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdb
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestDefaultForReplicas(t *testing.T) {
+	selector := map[string]string{"service": "foo"}
+
+	tests := []struct {
+		name     string
+		replicas int32
+		want     *intstr.IntOrString
+	}{
+		{
+			name:     "No PDB for a single replica",
+			replicas: 1,
+			want:     nil,
+		},
+		{
+			name:     "PDB for 3 replicas",
+			replicas: 3,
+			want:     &intstr.IntOrString{Type: intstr.Int, IntVal: 2},
+		},
+		{
+			name:     "PDB for 5 replicas",
+			replicas: 5,
+			want:     &intstr.IntOrString{Type: intstr.Int, IntVal: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := DefaultForReplicas("foo", "namespace", tt.replicas, selector)
+
+			if tt.want == nil {
+				g.Expect(p).To(BeNil())
+				return
+			}
+			g.Expect(p).NotTo(BeNil())
+			g.Expect(p.Name).To(Equal("foo"))
+			g.Expect(p.Namespace).To(Equal("namespace"))
+			g.Expect(p.Spec.MinAvailable).To(Equal(tt.want))
+			g.Expect(p.Spec.MaxUnavailable).To(BeNil())
+			g.Expect(p.Spec.Selector.MatchLabels).To(Equal(selector))
+		})
+	}
+}
+
+func TestIsReady(t *testing.T) {
+	tests := []struct {
+		name   string
+		status policyv1.PodDisruptionBudgetStatus
+		want   bool
+	}{
+		{
+			name: "Ready",
+			status: policyv1.PodDisruptionBudgetStatus{
+				ObservedGeneration: 1,
+				ExpectedPods:       3,
+				CurrentHealthy:     3,
+				DesiredHealthy:     2,
+				DisruptionsAllowed: 1,
+				Conditions: []metav1.Condition{
+					{
+						Type:   policyv1.DisruptionAllowedCondition,
+						Status: metav1.ConditionTrue,
+						Reason: policyv1.SufficientPodsReason,
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "Not yet observed",
+			status: policyv1.PodDisruptionBudgetStatus{},
+			want:   false,
+		},
+		{
+			name: "Old generation observed",
+			status: policyv1.PodDisruptionBudgetStatus{
+				ObservedGeneration: 0,
+				ExpectedPods:       3,
+			},
+			want: false,
+		},
+		{
+			name: "No pods",
+			status: policyv1.PodDisruptionBudgetStatus{
+				ObservedGeneration: 1,
+				ExpectedPods:       0,
+			},
+			want: false,
+		},
+		{
+			name: "No healthy pods",
+			status: policyv1.PodDisruptionBudgetStatus{
+				ObservedGeneration: 1,
+				ExpectedPods:       3,
+				CurrentHealthy:     0,
+				DesiredHealthy:     2,
+				DisruptionsAllowed: 0,
+				Conditions: []metav1.Condition{
+					{
+						Type:   policyv1.DisruptionAllowedCondition,
+						Status: metav1.ConditionFalse,
+						Reason: policyv1.InsufficientPodsReason,
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "Sync failed",
+			status: policyv1.PodDisruptionBudgetStatus{
+				ObservedGeneration: 1,
+				ExpectedPods:       3,
+				Conditions: []metav1.Condition{
+					{
+						Type:   policyv1.DisruptionAllowedCondition,
+						Status: metav1.ConditionFalse,
+						Reason: policyv1.SyncFailedReason,
+					},
+				},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			budget := DefaultForReplicas("foo", "namespace", 3, map[string]string{"service": "foo"})
+			budget.Generation = 1
+			budget.Status = tt.status
+
+			g.Expect(NewPDB(budget, time.Second).IsReady()).To(Equal(tt.want))
+		})
+	}
+}
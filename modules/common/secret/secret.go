@@ -17,8 +17,13 @@ limitations under the License.
 package secret
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,12 +36,109 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// NewSecret returns an initialized Secret.
+func NewSecret(
+	secret *corev1.Secret,
+	timeout time.Duration,
+) *Secret {
+	return &Secret{
+		secret:  secret,
+		timeout: timeout,
+	}
+}
+
+// CreateOrPatch - creates or patches a secret, reconciles after Xs if object won't exist.
+func (s *Secret) CreateOrPatch(
+	ctx context.Context,
+	h *helper.Helper,
+) (ctrl.Result, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.secret.Name,
+			Namespace: s.secret.Namespace,
+		},
+	}
+
+	op, err := controllerutil.CreateOrPatch(ctx, h.GetClient(), secret, func() error {
+		// Secret type is immutable so we set this value only if
+		// a new object is going to be created
+		if secret.ObjectMeta.CreationTimestamp.IsZero() {
+			secret.Type = s.secret.Type
+		}
+		secret.Annotations = util.MergeStringMaps(s.secret.Annotations, secret.Annotations)
+		secret.Labels = util.MergeStringMaps(s.secret.Labels, secret.Labels)
+		secret.Immutable = s.secret.Immutable
+		secret.Data = s.secret.Data
+		secret.StringData = s.secret.StringData
+
+		err := controllerutil.SetControllerReference(h.GetBeforeObject(), secret, h.GetScheme())
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			h.GetLogger().Info(fmt.Sprintf("Secret %s not found, reconcile in %s", secret.Name, s.timeout))
+			return ctrl.Result{RequeueAfter: s.timeout}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if op != controllerutil.OperationResultNone {
+		h.GetLogger().Info(fmt.Sprintf("Secret %s - %s", secret.Name, op))
+	}
+
+	s.hash, err = Hash(secret)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error calculating configuration hash: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// Delete - delete a secret.
+func (s *Secret) Delete(
+	ctx context.Context,
+	h *helper.Helper,
+) error {
+	err := h.GetClient().Delete(ctx, s.secret)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("Error deleting secret %s: %w", s.secret.Name, err)
+	}
+
+	return nil
+}
+
+// GetSecret - get the secret object.
+func (s *Secret) GetSecret() corev1.Secret {
+	return *s.secret
+}
+
+// GetHash - returns the hash of the secret data after CreateOrPatch
+func (s *Secret) GetHash() string {
+	return s.hash
+}
+
+// SecretRotationLabel - label set by RotateSecret on the created secrets,
+// the value is the base name of the rotated secret
+const SecretRotationLabel = "secret.openstack.org/rotation"
+
+// SecretRotationGenerationAnnotation - annotation set by RotateSecret on the
+// created secrets, the value is a generation number increased with every
+// rotation and used to find the latest secret
+const SecretRotationGenerationAnnotation = "secret.openstack.org/rotation-generation"
+
+// ErrSecretImmutable - returned when the data of an existing immutable secret would change
+var ErrSecretImmutable = errors.New("secret is immutable and its data changed")
+
 // Hash function creates a hash of a Secret's Data and StringData fields and
 // returns it as a safe encoded string.
 func Hash(secret *corev1.Secret) (string, error) {
@@ -58,6 +160,27 @@ func Hash(secret *corev1.Secret) (string, error) {
 	return util.ObjectHash(data)
 }
 
+// HashOfKeys function creates a hash of the values of the given keys of a
+// Secret and returns it as a safe encoded string. Values in StringData take
+// precedence over the ones in Data, like they do when the secret gets written.
+// Changes to other keys or the secret Type do not change the hash, a missing key
+// results in a different hash than a key with an empty value.
+func HashOfKeys(secret *corev1.Secret, keys []string) (string, error) {
+	if secret == nil {
+		return "", fmt.Errorf("nil Secret doesn't have data to hash")
+	}
+
+	data := map[string][]byte{}
+	for _, key := range keys {
+		if val, ok := secret.StringData[key]; ok {
+			data[key] = []byte(val)
+		} else if val, ok := secret.Data[key]; ok {
+			data[key] = val
+		}
+	}
+	return util.ObjectHash(data)
+}
+
 // GetSecret - get secret by name and namespace
 func GetSecret(
 	ctx context.Context,
@@ -167,32 +290,35 @@ func createOrUpdateSecret(
 	// create or update the CM
 	op, err := controllerutil.CreateOrPatch(ctx, h.GetClient(), secret, func() error {
 		secret.Labels = util.MergeStringMaps(secret.Labels, st.Labels)
-		// add data from templates
-		renderedTemplateData, err := util.GetTemplateData(st)
+		newData, err := renderSecretData(h, st)
 		if err != nil {
 			return err
 		}
-		dataString := renderedTemplateData
-
-		// add provided custom data to dataString
-		// Note: this can overwrite data rendered from GetTemplateData() if key is same
-		if len(st.CustomData) > 0 {
-			for k, v := range st.CustomData {
-				vExpanded, err := util.ExecuteTemplateData(v, st.ConfigOptions)
-				if err == nil {
-					dataString[k] = vExpanded
-				} else {
-					h.GetLogger().Info(fmt.Sprintf("Skipped customData expansion due to: %s", err))
-					dataString[k] = v
-				}
+
+		// The data of an immutable secret can not be changed. Report it in a
+		// way the caller can act on instead of failing on the API validation.
+		if isImmutable(secret) {
+			if !dataEqual(secret.Data, newData) {
+				return fmt.Errorf("%w: %s/%s, rotate the secret by using a new name", ErrSecretImmutable, secret.Namespace, secret.Name)
 			}
+		} else {
+			secret.Immutable = st.Immutable
 		}
 
-		for k, d := range dataString {
-			data[k] = []byte(d)
+		for k, d := range newData {
+			data[k] = d
 		}
 		secret.Data = data
 
+		if st.HashLabelKey != "" {
+			hashLabel, err := hashLabelValue(secret)
+			if err != nil {
+				return err
+			}
+			util.InitMap(&secret.Labels)
+			secret.Labels[st.HashLabelKey] = hashLabel
+		}
+
 		// Only set controller ref if namespaces are equal, else we hit an error
 		if obj.GetNamespace() == secret.Namespace {
 			if !st.SkipSetOwner {
@@ -228,6 +354,78 @@ func createOrUpdateSecret(
 	return secretHash, op, nil
 }
 
+// hashLabelValue - returns the content hash of the secret, as returned by
+// Hash for the secret stored in the API, truncated to be usable as label value
+func hashLabelValue(secret *corev1.Secret) (string, error) {
+	s := secret.DeepCopy()
+	// the API server defaults the type of new secrets
+	if s.Type == "" {
+		s.Type = corev1.SecretTypeOpaque
+	}
+	hash, err := Hash(s)
+	if err != nil {
+		return "", fmt.Errorf("error calculating configuration hash: %w", err)
+	}
+	if len(hash) > validation.LabelValueMaxLength {
+		hash = hash[:validation.LabelValueMaxLength]
+	}
+
+	return hash, nil
+}
+
+// renderSecretData - renders the templates and custom data of st into secret data
+func renderSecretData(
+	h *helper.Helper,
+	st util.Template,
+) (map[string][]byte, error) {
+	// add data from templates
+	renderedTemplateData, err := util.GetTemplateData(st)
+	if err != nil {
+		return nil, err
+	}
+	dataString := renderedTemplateData
+
+	// add provided custom data to dataString
+	// Note: this can overwrite data rendered from GetTemplateData() if key is same
+	if len(st.CustomData) > 0 {
+		for k, v := range st.CustomData {
+			vExpanded, err := util.ExecuteTemplateData(v, st.ConfigOptions)
+			if err == nil {
+				dataString[k] = vExpanded
+			} else {
+				h.GetLogger().Info(fmt.Sprintf("Skipped customData expansion due to: %s", err))
+				dataString[k] = v
+			}
+		}
+	}
+
+	data := make(map[string][]byte, len(dataString))
+	for k, d := range dataString {
+		data[k] = []byte(d)
+	}
+
+	return data, nil
+}
+
+// isImmutable - returns true if the secret exists and is marked immutable
+func isImmutable(secret *corev1.Secret) bool {
+	return !secret.CreationTimestamp.IsZero() &&
+		secret.Immutable != nil && *secret.Immutable
+}
+
+// dataEqual - compares two secret data maps
+func dataEqual(a map[string][]byte, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || !bytes.Equal(v, bv) {
+			return false
+		}
+	}
+	return true
+}
+
 // createOrGetCustomSecret - create custom secret or retrieve it, if one already exists
 // finally return configuration hash
 func createOrGetCustomSecret(
@@ -313,6 +511,163 @@ func EnsureSecrets(
 	return nil
 }
 
+// SecretsUsedByTemplates - returns the names of the secrets EnsureSecrets
+// creates or reads for the templates sts, in the order of the templates.
+// This can be used to set up watches for the secrets.
+func SecretsUsedByTemplates(sts []util.Template) []types.NamespacedName {
+	names := []types.NamespacedName{}
+	for _, st := range sts {
+		name := types.NamespacedName{Name: st.Name, Namespace: st.Namespace}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// RotateSecret - renders the secret template st and compares the content hash
+// with the latest secret created by RotateSecret for the base name st.Name.
+// If there is no such secret yet, or the content changed, a new secret named
+// <st.Name>-<suffixGenerator()> gets created. It returns the name and hash of
+// the secret to be used. Previous versions of the secret are kept and carry the
+// SecretRotationLabel, cleanup of the old secrets is left to the caller. The
+// latest secret is tracked by the SecretRotationGenerationAnnotation, which
+// gets increased with every rotation.
+//
+// This allows to combine rotation with immutable secrets, as the data of an
+// existing secret never changes.
+func RotateSecret(
+	ctx context.Context,
+	h *helper.Helper,
+	obj client.Object,
+	st util.Template,
+	suffixGenerator func() string,
+) (string, string, error) {
+	data, err := renderSecretData(h, st)
+	if err != nil {
+		return "", "", err
+	}
+
+	secretType := st.SecretType
+	if secretType == "" {
+		secretType = corev1.SecretTypeOpaque
+	}
+	hash, err := Hash(&corev1.Secret{Data: data, Type: secretType})
+	if err != nil {
+		return "", "", fmt.Errorf("error calculating configuration hash: %w", err)
+	}
+
+	secrets := &corev1.SecretList{}
+	err = h.GetClient().List(
+		ctx,
+		secrets,
+		client.InNamespace(st.Namespace),
+		client.MatchingLabels{SecretRotationLabel: st.Name},
+	)
+	if err != nil {
+		return "", "", fmt.Errorf("error listing secrets for %s: %w", st.Name, err)
+	}
+
+	generation := 0
+	if len(secrets.Items) > 0 {
+		// the latest secret is the one with the highest generation to compare with
+		sort.Slice(secrets.Items, func(i, j int) bool {
+			gi := rotationGeneration(&secrets.Items[i])
+			gj := rotationGeneration(&secrets.Items[j])
+			if gi == gj {
+				return secrets.Items[i].Name < secrets.Items[j].Name
+			}
+			return gi < gj
+		})
+		latest := secrets.Items[len(secrets.Items)-1]
+		generation = rotationGeneration(&latest)
+
+		latestHash, err := Hash(&latest)
+		if err != nil {
+			return "", "", fmt.Errorf("error calculating configuration hash: %w", err)
+		}
+		if latestHash == hash {
+			return latest.Name, hash, nil
+		}
+	}
+
+	rotated := st
+	rotated.Name = fmt.Sprintf("%s-%s", st.Name, suffixGenerator())
+	rotated.Labels = util.MergeStringMaps(st.Labels, map[string]string{SecretRotationLabel: st.Name})
+	rotated.Annotations = util.MergeStringMaps(
+		map[string]string{SecretRotationGenerationAnnotation: strconv.Itoa(generation + 1)},
+		st.Annotations,
+	)
+
+	newHash, _, err := createOrUpdateSecret(ctx, h, obj, rotated)
+	if err != nil {
+		return "", "", err
+	}
+	h.GetLogger().Info(fmt.Sprintf("Secret %s rotated to %s", st.Name, rotated.Name))
+
+	return rotated.Name, newHash, nil
+}
+
+// rotationGeneration - returns the rotation generation of secret, or 0 if
+// the annotation is missing or invalid
+func rotationGeneration(secret *corev1.Secret) int {
+	generation, err := strconv.Atoi(secret.Annotations[SecretRotationGenerationAnnotation])
+	if err != nil {
+		return 0
+	}
+	return generation
+}
+
+// AdoptSecret - sets owner as the controller of the existing, pre-created
+// secret name in namespace. This allows the operator to take over the lifecycle
+// of a secret provided by the user. Adopting a secret already controlled by
+// owner is a no-op, if the secret is controlled by a different object an
+// error is returned.
+func AdoptSecret(
+	ctx context.Context,
+	h *helper.Helper,
+	name string,
+	namespace string,
+	owner client.Object,
+) error {
+	secret, _, err := GetSecret(ctx, h, name, namespace)
+	if err != nil {
+		return err
+	}
+
+	if metav1.IsControlledBy(secret, owner) {
+		return nil
+	}
+
+	if ref := metav1.GetControllerOf(secret); ref != nil {
+		return fmt.Errorf("secret %s/%s is already owned by %s %s", namespace, name, ref.Kind, ref.Name)
+	}
+
+	orig := secret.DeepCopy()
+	err = controllerutil.SetControllerReference(owner, secret, h.GetScheme())
+	if err != nil {
+		return err
+	}
+
+	err = h.GetClient().Patch(ctx, secret, client.MergeFrom(orig))
+	if err != nil {
+		return util.WrapErrorForObject(
+			fmt.Sprintf("Failed to adopt secret %s", name),
+			secret,
+			err,
+		)
+	}
+
+	util.LogForObject(
+		h,
+		fmt.Sprintf("Secret %s in namespace %s adopted by %s", name, namespace, owner.GetName()),
+		secret,
+	)
+
+	return nil
+}
+
 // DeleteSecretsWithLabel - Delete all secrets in namespace of the obj matching label selector
 func DeleteSecretsWithLabel(
 	ctx context.Context,
@@ -334,6 +689,48 @@ func DeleteSecretsWithLabel(
 	return nil
 }
 
+// PruneSecretsWithLabel - Delete all secrets in namespace of the obj matching
+// label selector and controlled by obj, except the ones with a name in keep.
+// Can be used to clean up no longer referenced secrets, e.g. old versions of a
+// rotated secret. The label selector must not be empty.
+func PruneSecretsWithLabel(
+	ctx context.Context,
+	h *helper.Helper,
+	obj client.Object,
+	labelSelectorMap map[string]string,
+	keep []string,
+) error {
+	if len(labelSelectorMap) == 0 {
+		return fmt.Errorf("labels are required to prune secrets of %s", obj.GetName())
+	}
+
+	secrets := &corev1.SecretList{}
+	err := h.GetClient().List(
+		ctx,
+		secrets,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingLabels(labelSelectorMap),
+	)
+	if err != nil {
+		return fmt.Errorf("Error listing Secrets: %w", err)
+	}
+
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if slices.Contains(keep, secret.Name) || !metav1.IsControlledBy(secret, obj) {
+			continue
+		}
+
+		err = h.GetClient().Delete(ctx, secret)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return fmt.Errorf("Error deleting Secret %s: %w", secret.Name, err)
+		}
+		h.GetLogger().Info(fmt.Sprintf("Secret %s pruned", secret.Name))
+	}
+
+	return nil
+}
+
 // DeleteSecretsWithName - Delete names secret object in namespace
 func DeleteSecretsWithName(
 	ctx context.Context,
@@ -408,6 +805,23 @@ func GetDataFromSecret(
 	return data, ctrl.Result{}, nil
 }
 
+// HashFields - verifies the expected fields are in the secret and returns a
+// hash of their values, the same hash VerifySecret returns. This allows to
+// verify a secret which got already read without reading it again.
+func HashFields(secret *corev1.Secret, expectedFields []string) (string, error) {
+	// collect the secret values the caller expects to exist
+	values := [][]byte{}
+	for _, field := range expectedFields {
+		val, ok := secret.Data[field]
+		if !ok {
+			return "", fmt.Errorf("field %s not found in Secret %s", field, client.ObjectKeyFromObject(secret))
+		}
+		values = append(values, val)
+	}
+
+	return util.ObjectHash(values)
+}
+
 // VerifySecret - verifies if the Secret object exists and the expected fields
 // are in the Secret. It returns a hash of the values of the expected fields.
 func VerifySecret(
@@ -429,18 +843,7 @@ func VerifySecret(
 		return "", ctrl.Result{}, fmt.Errorf("Get secret %s failed: %w", secretName, err)
 	}
 
-	// collect the secret values the caller expects to exist
-	values := [][]byte{}
-	for _, field := range expectedFields {
-		val, ok := secret.Data[field]
-		if !ok {
-			err := fmt.Errorf("field %s not found in Secret %s", field, secretName)
-			return "", ctrl.Result{}, err
-		}
-		values = append(values, val)
-	}
-
-	hash, err := util.ObjectHash(values)
+	hash, err := HashFields(secret, expectedFields)
 	if err != nil {
 		return "", ctrl.Result{}, err
 	}
@@ -0,0 +1,210 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSecretsUsedByTemplates(t *testing.T) {
+	tests := []struct {
+		name string
+		sts  []util.Template
+		want []types.NamespacedName
+	}{
+		{
+			name: "No templates",
+			sts:  nil,
+			want: []types.NamespacedName{},
+		},
+		{
+			name: "Standard and custom templates",
+			sts: []util.Template{
+				{
+					Name:      "config-data",
+					Namespace: "openstack",
+					Type:      util.TemplateTypeConfig,
+				},
+				{
+					Name:      "scripts",
+					Namespace: "openstack",
+					Type:      util.TemplateTypeScripts,
+				},
+				{
+					Name:      "custom-config-data",
+					Namespace: "openstack",
+					Type:      util.TemplateTypeCustom,
+				},
+				{
+					Name:      "config-data",
+					Namespace: "other",
+					Type:      util.TemplateTypeNone,
+				},
+			},
+			want: []types.NamespacedName{
+				{Name: "config-data", Namespace: "openstack"},
+				{Name: "scripts", Namespace: "openstack"},
+				{Name: "custom-config-data", Namespace: "openstack"},
+				{Name: "config-data", Namespace: "other"},
+			},
+		},
+		{
+			name: "Duplicate templates",
+			sts: []util.Template{
+				{
+					Name:      "config-data",
+					Namespace: "openstack",
+					Type:      util.TemplateTypeConfig,
+				},
+				{
+					Name:      "config-data",
+					Namespace: "openstack",
+					Type:      util.TemplateTypeConfig,
+				},
+			},
+			want: []types.NamespacedName{
+				{Name: "config-data", Namespace: "openstack"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(SecretsUsedByTemplates(tt.sts)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHashOfKeys(t *testing.T) {
+	base := func() *corev1.Secret {
+		return &corev1.Secret{
+			Data: map[string][]byte{
+				"password":  []byte("12345678"),
+				"transport": []byte("rabbit://"),
+				"unrelated": []byte("foo"),
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+	}
+	keys := []string{"password", "transport"}
+
+	tests := []struct {
+		name       string
+		patch      func(s *corev1.Secret)
+		wantChange bool
+	}{
+		{
+			name:       "Unrelated key changed",
+			patch:      func(s *corev1.Secret) { s.Data["unrelated"] = []byte("bar") },
+			wantChange: false,
+		},
+		{
+			name:       "Unrelated key added",
+			patch:      func(s *corev1.Secret) { s.Data["new"] = []byte("bar") },
+			wantChange: false,
+		},
+		{
+			name:       "Type changed",
+			patch:      func(s *corev1.Secret) { s.Type = corev1.SecretTypeBasicAuth },
+			wantChange: false,
+		},
+		{
+			name:       "Same value in StringData",
+			patch:      func(s *corev1.Secret) { s.StringData = map[string]string{"password": "12345678"} },
+			wantChange: false,
+		},
+		{
+			name:       "Tracked key changed",
+			patch:      func(s *corev1.Secret) { s.Data["password"] = []byte("87654321") },
+			wantChange: true,
+		},
+		{
+			name:       "Tracked key changed in StringData",
+			patch:      func(s *corev1.Secret) { s.StringData = map[string]string{"transport": "amqp://"} },
+			wantChange: true,
+		},
+		{
+			name:       "Tracked key removed",
+			patch:      func(s *corev1.Secret) { delete(s.Data, "transport") },
+			wantChange: true,
+		},
+		{
+			name:       "Tracked key emptied",
+			patch:      func(s *corev1.Secret) { s.Data["transport"] = []byte{} },
+			wantChange: true,
+		},
+	}
+
+	g := NewWithT(t)
+	baseHash, err := HashOfKeys(base(), keys)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			s := base()
+			tt.patch(s)
+			hash, err := HashOfKeys(s, keys)
+			g.Expect(err).ToNot(HaveOccurred())
+			if tt.wantChange {
+				g.Expect(hash).NotTo(Equal(baseHash))
+			} else {
+				g.Expect(hash).To(Equal(baseHash))
+			}
+		})
+	}
+
+	_, err = HashOfKeys(nil, keys)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestHashFields(t *testing.T) {
+	g := NewWithT(t)
+
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+		Data: map[string][]byte{
+			"tls.crt": []byte("cert"),
+			"tls.key": []byte("key"),
+			"other":   []byte("other"),
+		},
+	}
+
+	hash, err := HashFields(s, []string{"tls.key", "tls.crt"})
+	g.Expect(err).ToNot(HaveOccurred())
+	want, err := util.ObjectHash([][]byte{[]byte("key"), []byte("cert")})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(hash).To(Equal(want))
+
+	// other fields do not change the hash
+	s.Data["other"] = []byte("changed")
+	same, err := HashFields(s, []string{"tls.key", "tls.crt"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(same).To(Equal(hash))
+
+	_, err = HashFields(s, []string{"tls.key", "ca.crt"})
+	g.Expect(err).To(MatchError(ContainSubstring("field ca.crt not found in Secret ns/test")))
+}
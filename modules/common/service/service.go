@@ -20,8 +20,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -29,10 +31,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	"github.com/openstack-k8s-operators/lib-common/modules/common/clusterdns"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -85,9 +90,31 @@ func NewService(
 		}
 	}
 
+	setSessionAffinityDefaults(&svc.service.Spec)
+
 	return svc, nil
 }
 
+// setSessionAffinityDefaults - sets the default ClientIP timeout if the
+// ClientIP session affinity is used without specifying a timeout. The
+// timeout would be defaulted by the API server anyways, setting it here
+// prevents that a patch of the service removes it.
+func setSessionAffinityDefaults(spec *corev1.ServiceSpec) {
+	if spec.SessionAffinity != corev1.ServiceAffinityClientIP {
+		return
+	}
+
+	if spec.SessionAffinityConfig == nil {
+		spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{}
+	}
+	if spec.SessionAffinityConfig.ClientIP == nil {
+		spec.SessionAffinityConfig.ClientIP = &corev1.ClientIPConfig{}
+	}
+	if spec.SessionAffinityConfig.ClientIP.TimeoutSeconds == nil {
+		spec.SessionAffinityConfig.ClientIP.TimeoutSeconds = ptr.To(corev1.DefaultClientIPServiceAffinitySeconds)
+	}
+}
+
 // GetClusterIPs - returns the cluster IPs of the created service
 func (s *Service) GetClusterIPs() []string {
 	return s.clusterIPs
@@ -108,6 +135,17 @@ func (s *Service) GetServiceHostname() string {
 	return s.serviceHostname
 }
 
+// GetServiceHostnameFQDN - returns the fully qualified service hostname
+// including the cluster domain, e.g. name.namespace.svc.cluster.local. If
+// clusterDomain is empty, the one returned by clusterdns.GetDNSClusterDomain
+// is used.
+func (s *Service) GetServiceHostnameFQDN(clusterDomain string) string {
+	if clusterDomain == "" {
+		clusterDomain = clusterdns.GetDNSClusterDomain()
+	}
+	return fmt.Sprintf("%s.%s", s.serviceHostname, clusterDomain)
+}
+
 // GetServiceHostnamePort - returns the service hostname with port if service port
 // is not nil, otherwise returns GetServiceHostname()
 func (s *Service) GetServiceHostnamePort() (string, string) {
@@ -146,6 +184,58 @@ func (s *Service) AddAnnotation(anno map[string]string) {
 	s.service.Annotations = util.MergeStringMaps(s.service.Annotations, anno)
 }
 
+// SetEndpointAnnotation - sets the AnnotationEndpointKey annotation of the service to the endpoint type,
+// an existing value gets replaced
+func (s *Service) SetEndpointAnnotation(e Endpoint) {
+	s.service.Annotations = util.MergeStringMaps(
+		map[string]string{AnnotationEndpointKey: e.String()}, s.service.Annotations)
+}
+
+// EnableTopologyAwareRouting - sets the AnnotationTopologyModeKey annotation of
+// the service to Auto, replacing an existing value, so that traffic is
+// preferably routed to endpoints in the same zone. If not set, the
+// InternalTrafficPolicy gets defaulted to Cluster, as topology aware routing is
+// not applied with the Local policy.
+func (s *Service) EnableTopologyAwareRouting() {
+	s.service.Annotations = util.MergeStringMaps(
+		map[string]string{AnnotationTopologyModeKey: "Auto"}, s.service.Annotations)
+	if s.service.Spec.InternalTrafficPolicy == nil {
+		s.service.Spec.InternalTrafficPolicy = ptr.To(corev1.ServiceInternalTrafficPolicyCluster)
+	}
+}
+
+// ConfigureMetalLB - sets the MetalLB address-pool, loadBalancerIPs and
+// allow-shared-ip annotations on the service, see ApplyMetalLBAnnotations.
+// Returns an error if the service is not of type LoadBalancer.
+func (s *Service) ConfigureMetalLB(addressPool string, loadBalancerIPs []string, sharedIPKey string) error {
+	if s.GetServiceType() != corev1.ServiceTypeLoadBalancer {
+		return fmt.Errorf("MetalLB annotations require service %s to be of type %s, not %s",
+			s.service.Name, corev1.ServiceTypeLoadBalancer, s.GetServiceType())
+	}
+
+	ApplyMetalLBAnnotations(s.service, addressPool, sharedIPKey, loadBalancerIPs)
+
+	return nil
+}
+
+// GetEndpointFromService - returns the endpoint type from the AnnotationEndpointKey
+// annotation of svc. Returns false if the annotation is missing or not a known
+// endpoint type.
+func GetEndpointFromService(svc *corev1.Service) (Endpoint, bool) {
+	value, ok := svc.GetAnnotations()[AnnotationEndpointKey]
+	if !ok {
+		return "", false
+	}
+
+	e := Endpoint(value)
+	switch e {
+	case EndpointAdmin, EndpointInternal, EndpointPublic:
+		return e, true
+	}
+
+	return "", false
+}
+
 // AddAnnotation - Adds annotation and merges it with the current set
 func (s *RoutedOverrideSpec) AddAnnotation(anno map[string]string) {
 	if s.EmbeddedLabelsAnnotations == nil {
@@ -184,12 +274,13 @@ func (s *Service) GetAPIEndpoint(endpointURL *string, protocol *Protocol, path s
 	var apiEndpoint *url.URL
 	var err error
 	if endpointURL != nil {
-		apiEndpoint, err = url.Parse(*endpointURL)
+		apiEndpoint, err = url.Parse(bracketIPv6URL(*endpointURL))
 		if err != nil {
 			return "", err
 		}
 	} else {
 		hostname, port := s.GetServiceHostnamePort()
+		hostname = bracketIPv6(hostname)
 
 		var endptURL string
 		if protocol != nil &&
@@ -211,6 +302,43 @@ func (s *Service) GetAPIEndpoint(endpointURL *string, protocol *Protocol, path s
 	return apiEndpoint.String() + path, nil
 }
 
+// bracketIPv6 - returns host wrapped in brackets if it is an IPv6 literal
+func bracketIPv6(host string) string {
+	if strings.Contains(host, ":") && net.ParseIP(host) != nil {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// bracketIPv6URL - wraps the host of the URL in brackets if it is an IPv6
+// literal without brackets, e.g. http://fd00::1/path. A port can not be
+// distinguished from the address in this case, so the whole host is expected
+// to be the address.
+func bracketIPv6URL(endpointURL string) string {
+	scheme, rest, found := strings.Cut(endpointURL, "://")
+	if !found {
+		return endpointURL
+	}
+	authority, path, _ := strings.Cut(rest, "/")
+	userinfo, host, found := strings.Cut(authority, "@")
+	if !found {
+		host = userinfo
+		userinfo = ""
+	} else {
+		userinfo += "@"
+	}
+
+	bracketed := bracketIPv6(host)
+	if bracketed == host {
+		return endpointURL
+	}
+	if strings.Contains(rest, "/") {
+		path = "/" + path
+	}
+
+	return scheme + "://" + userinfo + bracketed + path
+}
+
 // ToOverrideServiceSpec - convert corev1.ServiceSpec to OverrideServiceSpec
 func (s *Service) ToOverrideServiceSpec() (*OverrideServiceSpec, error) {
 	overrideServiceSpec := &OverrideServiceSpec{}
@@ -231,6 +359,81 @@ func (s *Service) ToOverrideServiceSpec() (*OverrideServiceSpec, error) {
 	return overrideServiceSpec, nil
 }
 
+// SpecHash - returns a hash of the user controllable fields of the service,
+// the labels, annotations and the spec fields which can be set via the
+// OverrideServiceSpec plus ports, selector and a headless ClusterIP. Fields
+// populated by the API server, like the cluster IPs, IP families and node
+// ports, are ignored, as well as the defaulted protocol and target port of the
+// ports and the spec fields which are set to the default of the API server.
+// This allows to detect if a service needs to be patched.
+func (s *Service) SpecHash() (string, error) {
+	overrideSpec, err := s.ToOverrideServiceSpec()
+	if err != nil {
+		return "", err
+	}
+	// unset the fields which are equal to the defaults of the API server
+	if overrideSpec.SessionAffinity == corev1.ServiceAffinityNone {
+		overrideSpec.SessionAffinity = ""
+	}
+	if overrideSpec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyTypeCluster {
+		overrideSpec.ExternalTrafficPolicy = ""
+	}
+	if ptr.Deref(overrideSpec.IPFamilyPolicy, "") == corev1.IPFamilyPolicySingleStack {
+		overrideSpec.IPFamilyPolicy = nil
+	}
+	if ptr.Deref(overrideSpec.InternalTrafficPolicy, "") == corev1.ServiceInternalTrafficPolicyCluster {
+		overrideSpec.InternalTrafficPolicy = nil
+	}
+
+	type servicePort struct {
+		Name        string             `json:"name,omitempty"`
+		Protocol    corev1.Protocol    `json:"protocol,omitempty"`
+		AppProtocol *string            `json:"appProtocol,omitempty"`
+		Port        int32              `json:"port"`
+		TargetPort  intstr.IntOrString `json:"targetPort,omitempty"`
+	}
+	ports := []servicePort{}
+	for _, p := range s.service.Spec.Ports {
+		port := servicePort{
+			Name:        p.Name,
+			Protocol:    p.Protocol,
+			AppProtocol: p.AppProtocol,
+			Port:        p.Port,
+			TargetPort:  p.TargetPort,
+		}
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal == 0 {
+			port.TargetPort = intstr.FromInt32(p.Port)
+		}
+		ports = append(ports, port)
+	}
+
+	clusterIP := ""
+	if s.service.Spec.ClusterIP == corev1.ClusterIPNone {
+		clusterIP = corev1.ClusterIPNone
+	}
+
+	return util.ObjectHash(struct {
+		Labels                   map[string]string    `json:"labels,omitempty"`
+		Annotations              map[string]string    `json:"annotations,omitempty"`
+		Spec                     *OverrideServiceSpec `json:"spec,omitempty"`
+		Ports                    []servicePort        `json:"ports,omitempty"`
+		Selector                 map[string]string    `json:"selector,omitempty"`
+		ClusterIP                string               `json:"clusterIP,omitempty"`
+		PublishNotReadyAddresses bool                 `json:"publishNotReadyAddresses,omitempty"`
+	}{
+		Labels:                   s.service.Labels,
+		Annotations:              s.service.Annotations,
+		Spec:                     overrideSpec,
+		Ports:                    ports,
+		Selector:                 s.service.Spec.Selector,
+		ClusterIP:                clusterIP,
+		PublishNotReadyAddresses: s.service.Spec.PublishNotReadyAddresses,
+	})
+}
+
 // GenericService func
 func GenericService(svcInfo *GenericServiceDetails) *corev1.Service {
 	ports := svcInfo.Ports
@@ -241,7 +444,8 @@ func GenericService(svcInfo *GenericServiceDetails) *corev1.Service {
 				Port: svcInfo.Port.Port,
 				// corev1.ProtocolTCP/ corev1.ProtocolUDP/ corev1.ProtocolSCTP
 				// - https://pkg.go.dev/k8s.io/api@v0.23.6/core/v1#Protocol
-				Protocol: svcInfo.Port.Protocol,
+				Protocol:    svcInfo.Port.Protocol,
+				AppProtocol: svcInfo.Port.AppProtocol,
 			},
 		}
 
@@ -262,6 +466,26 @@ func GenericService(svcInfo *GenericServiceDetails) *corev1.Service {
 	}
 }
 
+// NewHeadlessService returns an initialized headless Service for svcInfo.
+// The ClusterIP of the service is always set to None. As headless services
+// must be of type ClusterIP an error is returned if the override sets another
+// service type, e.g. LoadBalancer or NodePort.
+func NewHeadlessService(
+	svcInfo *GenericServiceDetails,
+	timeout time.Duration,
+	override *OverrideSpec,
+) (*Service, error) {
+	if override != nil && override.Spec != nil &&
+		override.Spec.Type != "" && override.Spec.Type != corev1.ServiceTypeClusterIP {
+		return nil, fmt.Errorf("headless service %s can not be of type %s", svcInfo.Name, override.Spec.Type)
+	}
+
+	headless := *svcInfo
+	headless.ClusterIP = corev1.ClusterIPNone
+
+	return NewService(GenericService(&headless), timeout, override)
+}
+
 // MetalLBService func
 // NOTE: (mschuppert) deprecated, can be removed when external endpoint creation moved to openstack-operator
 func MetalLBService(svcInfo *MetalLBServiceDetails) *corev1.Service {
@@ -273,7 +497,8 @@ func MetalLBService(svcInfo *MetalLBServiceDetails) *corev1.Service {
 				Port: svcInfo.Port.Port,
 				// corev1.ProtocolTCP/ corev1.ProtocolUDP/ corev1.ProtocolSCTP
 				// - https://pkg.go.dev/k8s.io/api@v0.23.6/core/v1#Protocol
-				Protocol: svcInfo.Port.Protocol,
+				Protocol:    svcInfo.Port.Protocol,
+				AppProtocol: svcInfo.Port.AppProtocol,
 			},
 		}
 
@@ -294,11 +519,78 @@ func MetalLBService(svcInfo *MetalLBServiceDetails) *corev1.Service {
 	}
 }
 
+// ApplyMetalLBAnnotations - sets the MetalLB address-pool, allow-shared-ip and
+// loadBalancerIPs annotations on the service. An annotation gets removed if
+// the corresponding input is empty.
+func ApplyMetalLBAnnotations(
+	svc *corev1.Service,
+	pool string,
+	sharedKey string,
+	loadBalancerIPs []string,
+) {
+	if svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+
+	metalLBAnnotations := map[string]string{
+		MetalLBAddressPoolAnnotation:   pool,
+		MetalLBAllowSharedIPAnnotation: sharedKey,
+		MetalLBLoadBalancerIPs:         strings.Join(loadBalancerIPs, ","),
+	}
+
+	for key, val := range metalLBAnnotations {
+		if val == "" {
+			delete(svc.Annotations, key)
+			continue
+		}
+		svc.Annotations[key] = val
+	}
+}
+
+// ValidateSelectorMatches - returns an error if the selector of the service is
+// not a subset of the podLabels, which would result in a service without endpoints.
+// A service without selector is not validated.
+func ValidateSelectorMatches(svc *corev1.Service, podLabels map[string]string) error {
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+
+	if !labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(podLabels)) {
+		return fmt.Errorf("selector %v of service %s/%s does not match pod labels %v",
+			svc.Spec.Selector, svc.Namespace, svc.Name, podLabels)
+	}
+
+	return nil
+}
+
 // CreateOrPatch - creates or patches a service, reconciles after Xs if object won't exist.
 func (s *Service) CreateOrPatch(
 	ctx context.Context,
 	h *helper.Helper,
 ) (ctrl.Result, error) {
+	ctrlResult, _, err := s.createOrPatch(ctx, h, h.GetBeforeObject())
+	return ctrlResult, err
+}
+
+// CreateOrPatchWithOp - creates or patches a service like CreateOrPatch and
+// additionally returns the controllerutil.OperationResult, which allows the
+// caller to e.g. distinguish between a created and an already existing service.
+// As the spec of the service gets set to the desired spec, fields defaulted by
+// the API server differ on every patch, so an unchanged service can report
+// controllerutil.OperationResultUpdated.
+func (s *Service) CreateOrPatchWithOp(
+	ctx context.Context,
+	h *helper.Helper,
+) (ctrl.Result, controllerutil.OperationResult, error) {
+	return s.createOrPatch(ctx, h, h.GetBeforeObject())
+}
+
+// createOrPatch - creates or patches the service with owner as controller reference
+func (s *Service) createOrPatch(
+	ctx context.Context,
+	h *helper.Helper,
+	owner client.Object,
+) (ctrl.Result, controllerutil.OperationResult, error) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      s.service.Name,
@@ -311,7 +603,7 @@ func (s *Service) CreateOrPatch(
 		service.Annotations = util.MergeStringMaps(s.service.Annotations, service.Annotations)
 		service.Spec = s.service.Spec
 
-		err := controllerutil.SetControllerReference(h.GetBeforeObject(), service, h.GetScheme())
+		err := controllerutil.SetControllerReference(owner, service, h.GetScheme())
 		if err != nil {
 			return err
 		}
@@ -321,9 +613,9 @@ func (s *Service) CreateOrPatch(
 	if err != nil {
 		if k8s_errors.IsNotFound(err) {
 			h.GetLogger().Info(fmt.Sprintf("Service %s not found, reconcile in %s", service.Name, s.timeout))
-			return ctrl.Result{RequeueAfter: s.timeout}, nil
+			return ctrl.Result{RequeueAfter: s.timeout}, op, nil
 		}
-		return ctrl.Result{}, err
+		return ctrl.Result{}, op, err
 	}
 	if op != controllerutil.OperationResultNone {
 		h.GetLogger().Info(fmt.Sprintf("Service %s - %s", service.Name, op))
@@ -339,11 +631,11 @@ func (s *Service) CreateOrPatch(
 				s.externalIPs = append(s.externalIPs, ingr.IP)
 			}
 		} else {
-			return ctrl.Result{}, fmt.Errorf("%s LoadBalancer IP still pending", s.service.Name)
+			return ctrl.Result{}, op, fmt.Errorf("%s LoadBalancer IP still pending", s.service.Name)
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{}, op, nil
 }
 
 // Delete - delete a service.
@@ -361,6 +653,63 @@ func (s *Service) Delete(
 	return nil
 }
 
+// ReconcileServices - creates or patches all desired services with owner as
+// controller reference and deletes the services in the namespace of the owner
+// which carry svcLabels and are controlled by the owner, but are not in the
+// desired list. The svcLabels are added to each desired service, overriding
+// conflicting labels, so they are considered on the next reconcile. The
+// desired services are not modified. It returns the ctrl.Result per service
+// name. svcLabels must not be empty, to not prune unrelated services.
+func ReconcileServices(
+	ctx context.Context,
+	h *helper.Helper,
+	owner client.Object,
+	desired []*corev1.Service,
+	svcLabels map[string]string,
+) (map[string]ctrl.Result, error) {
+	if len(svcLabels) == 0 {
+		return nil, fmt.Errorf("labels are required to reconcile services of %s", owner.GetName())
+	}
+
+	results := map[string]ctrl.Result{}
+	for _, svc := range desired {
+		svc = svc.DeepCopy()
+		svc.Labels = util.MergeStringMaps(svcLabels, svc.Labels)
+		s, err := NewService(svc, helper.ShortRequeue, nil)
+		if err != nil {
+			return results, err
+		}
+
+		results[svc.Name], _, err = s.createOrPatch(ctx, h, owner)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	serviceList := &corev1.ServiceList{}
+	listOpts := []client.ListOption{
+		client.InNamespace(owner.GetNamespace()),
+		client.MatchingLabels(svcLabels),
+	}
+	if err := h.GetClient().List(ctx, serviceList, listOpts...); err != nil {
+		return results, fmt.Errorf("Error listing services for %s: %w", owner.GetName(), err)
+	}
+
+	for idx := range serviceList.Items {
+		svc := &serviceList.Items[idx]
+		if _, ok := results[svc.Name]; ok || !metav1.IsControlledBy(svc, owner) {
+			continue
+		}
+		err := h.GetClient().Delete(ctx, svc)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return results, fmt.Errorf("Error deleting service %s: %w", svc.Name, err)
+		}
+		h.GetLogger().Info(fmt.Sprintf("Service %s - deleted", svc.Name))
+	}
+
+	return results, nil
+}
+
 // DeleteServicesWithLabel - Delete all services in namespace of the obj matching label selector
 func DeleteServicesWithLabel(
 	ctx context.Context,
@@ -383,11 +732,12 @@ func DeleteServicesWithLabel(
 		return err
 	}
 
-	// delete all pods
-	for _, pod := range serviceList.Items {
-		err := h.GetClient().Delete(ctx, &pod)
+	// delete all services
+	for i := range serviceList.Items {
+		svc := &serviceList.Items[i]
+		err := h.GetClient().Delete(ctx, svc)
 		if err != nil && !k8s_errors.IsNotFound(err) {
-			err = fmt.Errorf("Error deleting service %s: %w", pod.Name, err)
+			err = fmt.Errorf("Error deleting service %s: %w", svc.Name, err)
 			return err
 		}
 	}
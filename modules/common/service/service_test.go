@@ -238,6 +238,79 @@ func TestGenericService(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Service with deprecated port and AppProtocol",
+			service: GenericServiceDetails{
+				Name:      "foo",
+				Namespace: "namespace",
+				Labels:    map[string]string{},
+				Selector:  map[string]string{},
+				Port: GenericServicePort{
+					Name:        "port",
+					Port:        int32(80),
+					Protocol:    corev1.ProtocolTCP,
+					AppProtocol: ptr.To("h2c"),
+				},
+			},
+			want: corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "namespace",
+					Labels:    map[string]string{},
+				},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{
+						{
+							Name:        "port",
+							Protocol:    corev1.ProtocolTCP,
+							AppProtocol: ptr.To("h2c"),
+							Port:        int32(80),
+							TargetPort:  intstr.FromInt(0),
+							NodePort:    0,
+						},
+					},
+					Selector:                 map[string]string{},
+					Type:                     corev1.ServiceTypeClusterIP,
+					PublishNotReadyAddresses: false,
+				},
+			},
+		},
+		{
+			name: "Service with deprecated port without AppProtocol",
+			service: GenericServiceDetails{
+				Name:      "foo",
+				Namespace: "namespace",
+				Labels:    map[string]string{},
+				Selector:  map[string]string{},
+				Port: GenericServicePort{
+					Name:     "port",
+					Port:     int32(80),
+					Protocol: corev1.ProtocolTCP,
+				},
+			},
+			want: corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "namespace",
+					Labels:    map[string]string{},
+				},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{
+						{
+							Name:        "port",
+							Protocol:    corev1.ProtocolTCP,
+							AppProtocol: nil,
+							Port:        int32(80),
+							TargetPort:  intstr.FromInt(0),
+							NodePort:    0,
+						},
+					},
+					Selector:                 map[string]string{},
+					Type:                     corev1.ServiceTypeClusterIP,
+					PublishNotReadyAddresses: false,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -351,11 +424,352 @@ func TestNewService(t *testing.T) {
 	}
 }
 
+func TestNewHeadlessService(t *testing.T) {
+	tests := []struct {
+		name     string
+		override *OverrideSpec
+		wantErr  bool
+	}{
+		{
+			name:     "No override",
+			override: nil,
+		},
+		{
+			name: "ClusterIP type override",
+			override: &OverrideSpec{
+				Spec: &OverrideServiceSpec{
+					Type: corev1.ServiceTypeClusterIP,
+				},
+			},
+		},
+		{
+			name: "LoadBalancer type override",
+			override: &OverrideSpec{
+				Spec: &OverrideServiceSpec{
+					Type: corev1.ServiceTypeLoadBalancer,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "NodePort type override",
+			override: &OverrideSpec{
+				Spec: &OverrideServiceSpec{
+					Type: corev1.ServiceTypeNodePort,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			svcInfo := &GenericServiceDetails{
+				Name:                     "foo",
+				Namespace:                "namespace",
+				Selector:                 map[string]string{"foo": "bar"},
+				Ports:                    portHTTP,
+				ClusterIP:                "10.0.0.1",
+				PublishNotReadyAddresses: true,
+			}
+
+			service, err := NewHeadlessService(svcInfo, timeout, tt.override)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(service.GetSpec().ClusterIP).To(Equal(corev1.ClusterIPNone))
+			g.Expect(service.GetSpec().Type).To(Equal(corev1.ServiceTypeClusterIP))
+			g.Expect(service.GetSpec().PublishNotReadyAddresses).To(BeTrue())
+			// the passed details are not modified
+			g.Expect(svcInfo.ClusterIP).To(Equal("10.0.0.1"))
+		})
+	}
+}
+
+func TestNewServiceSessionAffinity(t *testing.T) {
+	tests := []struct {
+		name     string
+		override OverrideSpec
+		want     *corev1.SessionAffinityConfig
+	}{
+		{
+			name:     "No session affinity",
+			override: OverrideSpec{},
+			want:     nil,
+		},
+		{
+			name: "ClientIP session affinity without timeout",
+			override: OverrideSpec{
+				Spec: &OverrideServiceSpec{
+					SessionAffinity: corev1.ServiceAffinityClientIP,
+				},
+			},
+			want: &corev1.SessionAffinityConfig{
+				ClientIP: &corev1.ClientIPConfig{
+					TimeoutSeconds: ptr.To(corev1.DefaultClientIPServiceAffinitySeconds),
+				},
+			},
+		},
+		{
+			name: "ClientIP session affinity with custom timeout",
+			override: OverrideSpec{
+				Spec: &OverrideServiceSpec{
+					SessionAffinity: corev1.ServiceAffinityClientIP,
+					SessionAffinityConfig: &corev1.SessionAffinityConfig{
+						ClientIP: &corev1.ClientIPConfig{
+							TimeoutSeconds: ptr.To[int32](600),
+						},
+					},
+				},
+			},
+			want: &corev1.SessionAffinityConfig{
+				ClientIP: &corev1.ClientIPConfig{
+					TimeoutSeconds: ptr.To[int32](600),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			service, err := NewService(getServiceWithPort(svcClusterIP, portHTTP), timeout, &tt.override)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(service.GetSpec().SessionAffinityConfig).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestSpecHash(t *testing.T) {
+	g := NewWithT(t)
+
+	// service as created by the API server, with defaulted and populated fields
+	defaulted := getServiceWithPort(svcClusterIP, []corev1.ServicePort{
+		{
+			Name:       "foo",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       int32(80),
+			TargetPort: intstr.FromInt(80),
+		},
+	})
+	defaulted.Spec.ClusterIP = "10.0.0.1"
+	defaulted.Spec.ClusterIPs = []string{"10.0.0.1"}
+	defaulted.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol}
+	defaulted.Spec.IPFamilyPolicy = ptr.To(corev1.IPFamilyPolicySingleStack)
+	defaulted.Spec.InternalTrafficPolicy = ptr.To(corev1.ServiceInternalTrafficPolicyCluster)
+	defaulted.Spec.SessionAffinity = corev1.ServiceAffinityNone
+
+	tests := []struct {
+		name     string
+		service  *corev1.Service
+		override OverrideSpec
+		wantSame bool
+	}{
+		{
+			name:     "Same inputs",
+			service:  getServiceWithPort(svcClusterIP, portHTTP),
+			wantSame: true,
+		},
+		{
+			name:     "Server defaulted fields",
+			service:  defaulted,
+			wantSame: true,
+		},
+		{
+			name:     "Different port",
+			service:  getServiceWithPort(svcClusterIP, portHTTPS),
+			wantSame: false,
+		},
+		{
+			name:    "Different override",
+			service: getServiceWithPort(svcClusterIP, portHTTP),
+			override: OverrideSpec{
+				Spec: &OverrideServiceSpec{
+					Type: corev1.ServiceTypeLoadBalancer,
+				},
+			},
+			wantSame: false,
+		},
+		{
+			name:    "Different labels",
+			service: getServiceWithPort(svcClusterIP, portHTTP),
+			override: OverrideSpec{
+				EmbeddedLabelsAnnotations: &EmbeddedLabelsAnnotations{
+					Labels: map[string]string{"foo": "baz"},
+				},
+			},
+			wantSame: false,
+		},
+	}
+
+	service, err := NewService(getServiceWithPort(svcClusterIP, portHTTP), timeout, &OverrideSpec{})
+	g.Expect(err).ToNot(HaveOccurred())
+	hash, err := service.SpecHash()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(hash).NotTo(BeEmpty())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			other, err := NewService(tt.service, timeout, &tt.override)
+			g.Expect(err).ToNot(HaveOccurred())
+			otherHash, err := other.SpecHash()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(otherHash == hash).To(Equal(tt.wantSame))
+		})
+	}
+}
+
+func TestGetServiceHostnameFQDN(t *testing.T) {
+	tests := []struct {
+		name          string
+		clusterDomain string
+		want          string
+	}{
+		{
+			name:          "Default cluster domain",
+			clusterDomain: "",
+			want:          "foo.namespace.svc.cluster.local",
+		},
+		{
+			name:          "Custom cluster domain",
+			clusterDomain: "example.com",
+			want:          "foo.namespace.svc.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			service, err := NewService(getServiceWithPort(svcClusterIP, portHTTP), timeout, &OverrideSpec{})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(service.GetServiceHostnameFQDN(tt.clusterDomain)).To(Equal(tt.want))
+			g.Expect(service.GetServiceHostname()).To(Equal("foo.namespace.svc"))
+		})
+	}
+}
+
+func TestEnableTopologyAwareRouting(t *testing.T) {
+	tests := []struct {
+		name       string
+		override   OverrideSpec
+		wantAnno   map[string]string
+		wantPolicy *corev1.ServiceInternalTrafficPolicyType
+	}{
+		{
+			name:     "No override",
+			override: OverrideSpec{},
+			wantAnno: map[string]string{
+				AnnotationTopologyModeKey: "Auto",
+			},
+			wantPolicy: ptr.To(corev1.ServiceInternalTrafficPolicyCluster),
+		},
+		{
+			name: "Existing annotations and policy are preserved",
+			override: OverrideSpec{
+				EmbeddedLabelsAnnotations: &EmbeddedLabelsAnnotations{
+					Annotations: map[string]string{
+						"foo": "bar",
+					},
+				},
+				Spec: &OverrideServiceSpec{
+					InternalTrafficPolicy: ptr.To(corev1.ServiceInternalTrafficPolicyLocal),
+				},
+			},
+			wantAnno: map[string]string{
+				"foo":                     "bar",
+				AnnotationTopologyModeKey: "Auto",
+			},
+			wantPolicy: ptr.To(corev1.ServiceInternalTrafficPolicyLocal),
+		},
+		{
+			name: "Existing topology mode gets replaced",
+			override: OverrideSpec{
+				EmbeddedLabelsAnnotations: &EmbeddedLabelsAnnotations{
+					Annotations: map[string]string{
+						"foo":                     "bar",
+						AnnotationTopologyModeKey: "Disabled",
+					},
+				},
+			},
+			wantAnno: map[string]string{
+				"foo":                     "bar",
+				AnnotationTopologyModeKey: "Auto",
+			},
+			wantPolicy: ptr.To(corev1.ServiceInternalTrafficPolicyCluster),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			service, err := NewService(getServiceWithPort(svcClusterIP, portHTTP), timeout, &tt.override)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(service.GetAnnotations()[AnnotationTopologyModeKey]).NotTo(Equal("Auto"))
+
+			service.EnableTopologyAwareRouting()
+			g.Expect(service.GetAnnotations()).To(Equal(tt.wantAnno))
+			g.Expect(service.GetSpec().InternalTrafficPolicy).To(Equal(tt.wantPolicy))
+		})
+	}
+}
+
+func TestConfigureMetalLB(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  *corev1.Service
+		wantAnno map[string]string
+		wantErr  bool
+	}{
+		{
+			name:    "LoadBalancer service",
+			service: getServiceWithPort(svcLoadBalancer, portHTTP),
+			wantAnno: map[string]string{
+				MetalLBAddressPoolAnnotation:   "internalapi",
+				MetalLBAllowSharedIPAnnotation: "internalapi",
+				MetalLBLoadBalancerIPs:         "172.17.0.80,fd00:bbbb::80",
+			},
+		},
+		{
+			name:    "ClusterIP service",
+			service: getServiceWithPort(svcClusterIP, portHTTP),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			service, err := NewService(tt.service, timeout, &OverrideSpec{})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			err = service.ConfigureMetalLB("internalapi", []string{"172.17.0.80", "fd00:bbbb::80"}, "internalapi")
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(service.GetAnnotations()).NotTo(HaveKey(MetalLBAddressPoolAnnotation))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(service.GetAnnotations()).To(Equal(tt.wantAnno))
+		})
+	}
+}
+
 func TestGetAPIEndpoint(t *testing.T) {
 	tests := []struct {
 		name        string
 		service     *corev1.Service
 		endpointURL *string
+		hostname    string
 		proto       Protocol
 		port        string
 		path        string
@@ -425,6 +839,48 @@ func TestGetAPIEndpoint(t *testing.T) {
 			path:        "",
 			want:        "http://this.url",
 		},
+		{
+			name:        "Override EndpointURL IPv6 with path",
+			service:     getServiceWithPort(svcClusterIP, portCustom),
+			endpointURL: ptr.To("http://fd00:bbbb::1"),
+			proto:       ProtocolNone,
+			path:        "/path",
+			want:        "http://[fd00:bbbb::1]/path",
+		},
+		{
+			name:        "Override EndpointURL bracketed IPv6 with port",
+			service:     getServiceWithPort(svcClusterIP, portCustom),
+			endpointURL: ptr.To("http://[::1]:8080"),
+			proto:       ProtocolNone,
+			path:        "/path",
+			want:        "http://[::1]:8080/path",
+		},
+		{
+			name:        "Override EndpointURL IPv4 is unchanged",
+			service:     getServiceWithPort(svcClusterIP, portCustom),
+			endpointURL: ptr.To("https://172.17.0.80:8080/v3"),
+			proto:       ProtocolNone,
+			path:        "",
+			want:        "https://172.17.0.80:8080/v3",
+		},
+		{
+			name:        "HTTP IPv6 hostname non default 8080 port, no override",
+			service:     getServiceWithPort(svcClusterIP, portCustom),
+			endpointURL: nil,
+			hostname:    "::1",
+			proto:       ProtocolHTTP,
+			path:        "/path",
+			want:        "http://[::1]:8080/path",
+		},
+		{
+			name:        "HTTPS IPv6 hostname default 443 port, no override",
+			service:     getServiceWithPort(svcClusterIP, portHTTPS),
+			endpointURL: nil,
+			hostname:    "fd00:bbbb::1",
+			proto:       ProtocolHTTPS,
+			path:        "/path",
+			want:        "https://[fd00:bbbb::1]/path",
+		},
 	}
 
 	for _, tt := range tests {
@@ -433,6 +889,9 @@ func TestGetAPIEndpoint(t *testing.T) {
 
 			service, err := NewService(tt.service, timeout, nil)
 			g.Expect(err).ToNot(HaveOccurred())
+			if tt.hostname != "" {
+				service.serviceHostname = tt.hostname
+			}
 			url, err := service.GetAPIEndpoint(tt.endpointURL, ptr.To(tt.proto), tt.path)
 			g.Expect(err).ToNot(HaveOccurred())
 			g.Expect(url).To(Equal(tt.want))
@@ -633,3 +1092,187 @@ func TestRoutedOverrideSpecAddAnnotation(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyMetalLBAnnotations(t *testing.T) {
+	tests := []struct {
+		name            string
+		annotations     map[string]string
+		pool            string
+		sharedKey       string
+		loadBalancerIPs []string
+		want            map[string]string
+	}{
+		{
+			name: "All empty",
+			want: map[string]string{},
+		},
+		{
+			name:            "Set all annotations",
+			pool:            "internalapi",
+			sharedKey:       "internalapi",
+			loadBalancerIPs: []string{"172.17.0.80"},
+			want: map[string]string{
+				MetalLBAddressPoolAnnotation:   "internalapi",
+				MetalLBAllowSharedIPAnnotation: "internalapi",
+				MetalLBLoadBalancerIPs:         "172.17.0.80",
+			},
+		},
+		{
+			name:            "Dual stack loadBalancerIPs",
+			pool:            "internalapi",
+			loadBalancerIPs: []string{"172.17.0.80", "fd00:bbbb::80"},
+			want: map[string]string{
+				MetalLBAddressPoolAnnotation: "internalapi",
+				MetalLBLoadBalancerIPs:       "172.17.0.80,fd00:bbbb::80",
+			},
+		},
+		{
+			name: "Keep other annotations and remove empty ones",
+			annotations: map[string]string{
+				"foo":                          "bar",
+				MetalLBAddressPoolAnnotation:   "ctlplane",
+				MetalLBAllowSharedIPAnnotation: "ctlplane",
+				MetalLBLoadBalancerIPs:         "192.168.122.80",
+			},
+			pool: "internalapi",
+			want: map[string]string{
+				"foo":                        "bar",
+				MetalLBAddressPoolAnnotation: "internalapi",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: tt.annotations,
+				},
+			}
+			ApplyMetalLBAnnotations(svc, tt.pool, tt.sharedKey, tt.loadBalancerIPs)
+			g.Expect(svc.Annotations).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestValidateSelectorMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		selector  map[string]string
+		podLabels map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "No selector",
+			selector:  nil,
+			podLabels: map[string]string{"service": "foo"},
+			wantErr:   false,
+		},
+		{
+			name:      "Selector matches pod labels",
+			selector:  map[string]string{"service": "foo"},
+			podLabels: map[string]string{"service": "foo"},
+			wantErr:   false,
+		},
+		{
+			name:      "Selector is a subset of pod labels",
+			selector:  map[string]string{"service": "foo"},
+			podLabels: map[string]string{"service": "foo", "component": "api"},
+			wantErr:   false,
+		},
+		{
+			name:      "Selector value does not match",
+			selector:  map[string]string{"service": "foo"},
+			podLabels: map[string]string{"service": "bar"},
+			wantErr:   true,
+		},
+		{
+			name:      "Selector key missing in pod labels",
+			selector:  map[string]string{"service": "foo", "component": "api"},
+			podLabels: map[string]string{"service": "foo"},
+			wantErr:   true,
+		},
+		{
+			name:      "No pod labels",
+			selector:  map[string]string{"service": "foo"},
+			podLabels: nil,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "namespace",
+				},
+				Spec: corev1.ServiceSpec{
+					Selector: tt.selector,
+				},
+			}
+			err := ValidateSelectorMatches(svc, tt.podLabels)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestEndpointAnnotation(t *testing.T) {
+	for _, e := range []Endpoint{EndpointAdmin, EndpointInternal, EndpointPublic} {
+		t.Run(e.String(), func(t *testing.T) {
+			g := NewWithT(t)
+
+			s, err := NewService(svcClusterIP.DeepCopy(), time.Duration(5)*time.Second, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			s.SetEndpointAnnotation(e)
+			g.Expect(s.GetAnnotations()).To(HaveKeyWithValue(AnnotationEndpointKey, string(e)))
+
+			got, ok := GetEndpointFromService(s.service)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(got).To(Equal(e))
+		})
+	}
+
+	t.Run("overwrites existing annotation", func(t *testing.T) {
+		g := NewWithT(t)
+
+		svc := svcClusterIP.DeepCopy()
+		svc.Annotations = map[string]string{
+			"foo":                 "bar",
+			AnnotationEndpointKey: string(EndpointInternal),
+		}
+		s, err := NewService(svc, time.Duration(5)*time.Second, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		s.SetEndpointAnnotation(EndpointPublic)
+		g.Expect(s.GetAnnotations()).To(Equal(map[string]string{
+			"foo":                 "bar",
+			AnnotationEndpointKey: string(EndpointPublic),
+		}))
+	})
+
+	t.Run("missing annotation", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, ok := GetEndpointFromService(svcClusterIP.DeepCopy())
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("unknown endpoint type", func(t *testing.T) {
+		g := NewWithT(t)
+
+		svc := svcClusterIP.DeepCopy()
+		svc.Annotations = map[string]string{AnnotationEndpointKey: "private"}
+		_, ok := GetEndpointFromService(svc)
+		g.Expect(ok).To(BeFalse())
+	})
+}
@@ -51,6 +51,8 @@ const (
 	AnnotationEndpointKey = "endpoint"
 	// AnnotationHostnameKey -
 	AnnotationHostnameKey = "dnsmasq.network.openstack.org/hostname"
+	// AnnotationTopologyModeKey - enables topology aware routing of the service
+	AnnotationTopologyModeKey = "service.kubernetes.io/topology-mode"
 	// ProtocolHTTP -
 	ProtocolHTTP Protocol = "http"
 	// ProtocolHTTPS -
@@ -123,6 +125,8 @@ type GenericServicePort struct {
 	Name     string
 	Port     int32
 	Protocol corev1.Protocol // corev1.ProtocolTCP/ corev1.ProtocolUDP/ corev1.ProtocolSCTP - https://pkg.go.dev/k8s.io/api@v0.23.6/core/v1#Protocol
+	// AppProtocol - optional application protocol of the port, e.g. "http", "h2c" or "grpc"
+	AppProtocol *string
 }
 
 // MetalLBServiceDetails -
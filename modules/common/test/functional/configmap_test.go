@@ -0,0 +1,220 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package functional
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/configmap"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("configmap package", func() {
+	var namespace string
+
+	BeforeEach(func() {
+		// NOTE(gibi): We need to create a unique namespace for each test run
+		// as namespaces cannot be deleted in a locally running envtest. See
+		// https://book.kubebuilder.io/reference/envtest.html#namespace-usage-limitation
+		namespace = uuid.New().String()
+		th.CreateNamespace(namespace)
+		// We still request the delete of the Namespace to properly cleanup if
+		// we run the test in an existing cluster.
+		DeferCleanup(th.DeleteNamespace, namespace)
+	})
+
+	It("creates and patches configmaps with owner reference", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		tmpl := util.Template{
+			Name:       "test-cm",
+			Namespace:  namespace,
+			Type:       util.TemplateTypeNone,
+			Labels:     map[string]string{"label": "a"},
+			CustomData: map[string]string{"key": "value"},
+		}
+		envVars := map[string]env.Setter{}
+
+		err := configmap.EnsureConfigMaps(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(envVars).To(HaveKey("test-cm"))
+
+		cm := th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "test-cm"})
+		Expect(cm.Labels["label"]).To(Equal("a"))
+		Expect(cm.Data["key"]).To(Equal("value"))
+		Expect(cm.GetOwnerReferences()).To(HaveLen(1))
+		Expect(cm.GetOwnerReferences()[0]).To(HaveField("Name", "owner"))
+		oldHash, err := configmap.Hash(cm)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		tmpl.CustomData = map[string]string{"key": "new-value"}
+		err = configmap.EnsureConfigMaps(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		cm = th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "test-cm"})
+		Expect(cm.Data["key"]).To(Equal("new-value"))
+		newHash, err := configmap.Hash(cm)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(newHash).NotTo(Equal(oldHash))
+	})
+
+	It("does not require envVars", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		tmpl := util.Template{
+			Name:       "test-cm",
+			Namespace:  namespace,
+			Type:       util.TemplateTypeNone,
+			CustomData: map[string]string{"key": "value"},
+		}
+
+		err := configmap.EnsureConfigMaps(ctx, h, owner, []util.Template{tmpl}, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "test-cm"})
+	})
+
+	It("sets ownership labels on a configmap in a different namespace", func() {
+		otherNamespace := uuid.New().String()
+		th.CreateNamespace(otherNamespace)
+		DeferCleanup(th.DeleteNamespace, otherNamespace)
+
+		owner := th.CreateNAD(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{"config": "{}"})
+		tmpl := util.Template{
+			Name:         "test-cm",
+			Namespace:    otherNamespace,
+			Type:         util.TemplateTypeNone,
+			InstanceType: "Test",
+			CustomData:   map[string]string{"key": "value"},
+		}
+
+		err := configmap.EnsureConfigMaps(ctx, h, owner, []util.Template{tmpl}, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		cm := th.GetConfigMap(types.NamespacedName{Namespace: otherNamespace, Name: "test-cm"})
+		Expect(cm.GetOwnerReferences()).To(BeEmpty())
+		Expect(cm.Labels).To(HaveKeyWithValue("test.k8s.cni.cncf.io/uid", string(owner.GetUID())))
+		Expect(cm.Labels).To(HaveKeyWithValue("test.k8s.cni.cncf.io/namespace", namespace))
+		Expect(cm.Labels).To(HaveKeyWithValue("test.k8s.cni.cncf.io/name", "owner"))
+	})
+
+	It("moves a raw file from binary data to data once it is valid UTF-8", func() {
+		templates := GinkgoT().TempDir()
+		GinkgoT().Setenv("OPERATOR_TEMPLATES", templates)
+		configDir := filepath.Join(templates, "test", string(util.TemplateTypeConfig))
+		Expect(os.MkdirAll(configDir, 0755)).To(Succeed())
+		rawFile := filepath.Join(configDir, "raw.bin")
+		Expect(os.WriteFile(rawFile, []byte{0xff, 0xfe}, 0644)).To(Succeed())
+
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		tmpl := util.Template{
+			Name:         "raw-cm",
+			Namespace:    namespace,
+			Type:         util.TemplateTypeConfig,
+			InstanceType: "Test",
+			RawFiles:     []string{"raw.bin"},
+		}
+
+		err := configmap.EnsureConfigMaps(ctx, h, owner, []util.Template{tmpl}, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		cm := th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "raw-cm"})
+		Expect(cm.BinaryData).To(HaveKeyWithValue("raw.bin", []byte{0xff, 0xfe}))
+		Expect(cm.Data).NotTo(HaveKey("raw.bin"))
+
+		Expect(os.WriteFile(rawFile, []byte("text"), 0644)).To(Succeed())
+		err = configmap.EnsureConfigMaps(ctx, h, owner, []util.Template{tmpl}, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		cm = th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "raw-cm"})
+		Expect(cm.Data).To(HaveKeyWithValue("raw.bin", "text"))
+		Expect(cm.BinaryData).To(BeEmpty())
+	})
+
+	It("creates a custom configmap only once", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		tmpl := util.Template{
+			Name:      "custom-cm",
+			Namespace: namespace,
+			Type:      util.TemplateTypeCustom,
+			Labels:    map[string]string{"label": "a"},
+		}
+		envVars := map[string]env.Setter{}
+
+		err := configmap.EnsureConfigMaps(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(envVars).To(HaveKey("custom-cm"))
+
+		cm := th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "custom-cm"})
+		Expect(cm.Labels["label"]).To(Equal("a"))
+		Expect(cm.Data).To(BeEmpty())
+		Expect(cm.GetOwnerReferences()).To(HaveLen(1))
+
+		// the user provides the content of the custom configmap
+		cm.Data = map[string]string{"custom.conf": "user provided"}
+		Expect(cClient.Update(ctx, cm)).To(Succeed())
+
+		err = configmap.EnsureConfigMaps(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		cm = th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "custom-cm"})
+		Expect(cm.Data).To(HaveKeyWithValue("custom.conf", "user provided"))
+		hash, err := configmap.Hash(cm)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(envVars["custom-cm"]).NotTo(BeNil())
+
+		// the env var holds the hash of the user provided content
+		envVarList := env.MergeEnvs(nil, envVars)
+		Expect(envVarList).To(ContainElement(HaveField("Value", hash)))
+	})
+
+	It("gets data from a configmap", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		// GetDataFromConfigMap looks up the configmap in the namespace of the
+		// object being reconciled
+		ownerHelper, err := helper.NewHelper(owner, cClient, h.GetKClient(), h.GetScheme(), ctrl.Log)
+		Expect(err).NotTo(HaveOccurred())
+
+		// missing configmap requeues
+		data, result, err := configmap.GetDataFromConfigMap(ctx, ownerHelper, "test-cm", timeout, "key")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: timeout}))
+		Expect(data).To(BeEmpty())
+
+		th.CreateConfigMap(
+			types.NamespacedName{Namespace: namespace, Name: "test-cm"},
+			map[string]interface{}{"key": "value\n"},
+		)
+
+		// present key returns the value without the trailing newline
+		data, result, err = configmap.GetDataFromConfigMap(ctx, ownerHelper, "test-cm", timeout, "key")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(data).To(Equal("value"))
+
+		// missing key is an error
+		_, result, err = configmap.GetDataFromConfigMap(ctx, ownerHelper, "test-cm", timeout, "missing")
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("missing not found in config map test-cm"))
+		Expect(result).To(Equal(ctrl.Result{}))
+	})
+})
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package functional
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+var _ = Describe("helper package", func() {
+	var namespace string
+
+	BeforeEach(func() {
+		// NOTE(gibi): We need to create a unique namespace for each test run
+		// as namespaces cannot be deleted in a locally running envtest. See
+		// https://book.kubebuilder.io/reference/envtest.html#namespace-usage-limitation
+		namespace = uuid.New().String()
+		th.CreateNamespace(namespace)
+		// We still request the delete of the Namespace to properly cleanup if
+		// we run the test in an existing cluster.
+		DeferCleanup(th.DeleteNamespace, namespace)
+	})
+
+	It("creates and patches an object", func() {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-cm",
+				Namespace: namespace,
+			},
+		}
+		data := map[string]string{"key": "value"}
+		mutate := func() error {
+			cm.Data = data
+			return nil
+		}
+
+		result, op, err := helper.CreateOrPatchObject(ctx, h, cm, timeout, mutate)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(op).To(Equal(controllerutil.OperationResultCreated))
+
+		created := th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "test-cm"})
+		Expect(created.Data).To(HaveKeyWithValue("key", "value"))
+		Expect(created.GetOwnerReferences()).To(HaveLen(1))
+		Expect(created.GetOwnerReferences()[0]).To(HaveField("Name", h.GetBeforeObject().GetName()))
+
+		// same content is a noop
+		result, op, err = helper.CreateOrPatchObject(ctx, h, cm, timeout, mutate)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(op).To(Equal(controllerutil.OperationResultNone))
+
+		data = map[string]string{"key": "new-value"}
+		result, op, err = helper.CreateOrPatchObject(ctx, h, cm, timeout, mutate)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(op).To(Equal(controllerutil.OperationResultUpdated))
+
+		patched := th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "test-cm"})
+		Expect(patched.Data).To(HaveKeyWithValue("key", "new-value"))
+	})
+
+	It("requeues if the object can not be found", func() {
+		// the namespace does not exist so the create returns NotFound
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-cm",
+				Namespace: uuid.New().String(),
+			},
+		}
+
+		result, _, err := helper.CreateOrPatchObject(ctx, h, cm, timeout, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: timeout}))
+	})
+
+	It("adds and removes the finalizer", func() {
+		cm := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "test-cm"}, map[string]interface{}{})
+
+		changed, err := helper.EnsureFinalizer(ctx, h, cm)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "test-cm"}).Finalizers).To(
+			ConsistOf(h.GetFinalizer()))
+
+		// already present is a noop
+		changed, err = helper.EnsureFinalizer(ctx, h, cm)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+
+		changed, err = helper.RemoveFinalizer(ctx, h, cm)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "test-cm"}).Finalizers).To(BeEmpty())
+
+		// already absent is a noop
+		changed, err = helper.RemoveFinalizer(ctx, h, cm)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+	})
+
+	It("lists objects with label in pages", func() {
+		labels := map[string]string{"app": "paginated"}
+		for i := 0; i < 25; i++ {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("test-secret-%d", i),
+					Namespace: namespace,
+					Labels:    labels,
+				},
+			}
+			Expect(cClient.Create(ctx, secret)).To(Succeed())
+		}
+		// not matching the labels
+		th.CreateSecret(types.NamespacedName{Namespace: namespace, Name: "other"}, map[string][]byte{})
+
+		visited := []string{}
+		err := helper.ListWithLabelPaginated(ctx, cClient, &corev1.SecretList{}, namespace, labels, 10,
+			func(obj client.Object) error {
+				visited = append(visited, obj.GetName())
+				return nil
+			})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(visited).To(HaveLen(25))
+		Expect(visited).To(ContainElements("test-secret-0", "test-secret-12", "test-secret-24"))
+		Expect(visited).NotTo(ContainElement("other"))
+
+		// an error of fn stops the listing
+		calls := 0
+		err = helper.ListWithLabelPaginated(ctx, cClient, &corev1.SecretList{}, namespace, labels, 10,
+			func(obj client.Object) error {
+				calls++
+				return fmt.Errorf("stop")
+			})
+		Expect(err).To(MatchError("stop"))
+		Expect(calls).To(Equal(1))
+	})
+})
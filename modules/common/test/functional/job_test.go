@@ -21,6 +21,7 @@ import (
 	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/job"
 
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -30,6 +31,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
 )
 
 const (
@@ -126,6 +129,76 @@ var _ = Describe("job package", func() {
 		Expect(*gotJob.Spec.TTLSecondsAfterFinished).To(Equal(int32(600)))
 	})
 
+	It("defaults the active deadline if requested and not provided", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash).
+			WithDefaultActiveDeadline(3600)
+
+		_, err := j.DoJob(ctx, h)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		gotJob, err := job.GetJobWithName(ctx, h, exampleJob.Name, namespace)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(gotJob.Spec.ActiveDeadlineSeconds).NotTo(BeNil())
+		Expect(*gotJob.Spec.ActiveDeadlineSeconds).To(Equal(int64(3600)))
+	})
+
+	It("keeps the requested active deadline", func() {
+		exampleJob := getExampleJob(namespace)
+		var deadline int64 = 13
+		exampleJob.Spec.ActiveDeadlineSeconds = &deadline
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash).
+			WithDefaultActiveDeadline(3600)
+
+		_, err := j.DoJob(ctx, h)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		gotJob, err := job.GetJobWithName(ctx, h, exampleJob.Name, namespace)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(*gotJob.Spec.ActiveDeadlineSeconds).To(Equal(deadline))
+	})
+
+	It("does not set an active deadline by default", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash)
+
+		_, err := j.DoJob(ctx, h)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		gotJob, err := job.GetJobWithName(ctx, h, exampleJob.Name, namespace)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(gotJob.Spec.ActiveDeadlineSeconds).To(BeNil())
+	})
+
+	It("defaults the parallelism if requested and not provided", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash).
+			WithDefaultParallelism(2)
+
+		_, err := j.DoJob(ctx, h)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		gotJob, err := job.GetJobWithName(ctx, h, exampleJob.Name, namespace)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(gotJob.Spec.Parallelism).NotTo(BeNil())
+		Expect(*gotJob.Spec.Parallelism).To(Equal(int32(2)))
+	})
+
+	It("keeps the requested parallelism", func() {
+		exampleJob := getExampleJob(namespace)
+		var parallelism int32 = 3
+		exampleJob.Spec.Parallelism = &parallelism
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash).
+			WithDefaultParallelism(2)
+
+		_, err := j.DoJob(ctx, h)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		gotJob, err := job.GetJobWithName(ctx, h, exampleJob.Name, namespace)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(*gotJob.Spec.Parallelism).To(Equal(parallelism))
+	})
+
 	It("keeps the requested TTL", func() {
 		exampleJob := getExampleJob(namespace)
 		var ttl int32 = 13
@@ -155,6 +228,38 @@ var _ = Describe("job package", func() {
 		Expect(gotJob.Spec.TTLSecondsAfterFinished).To(BeNil())
 	})
 
+	It("adds sidecar containers as native sidecar init containers", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash)
+		_, err := j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		hashWithoutSidecar := j.GetHash()
+
+		exampleJob = getExampleJob(namespace)
+		exampleJob.Name = "test-job-sidecar"
+		sidecar := corev1.Container{
+			Name:    "test-sidecar",
+			Command: []string{"/bin/proxy"},
+			Image:   "test-sidecar-image",
+		}
+		j = job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash).WithSidecars(sidecar)
+
+		_, err = j.DoJob(ctx, h)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		gotJob, err := job.GetJobWithName(ctx, h, exampleJob.Name, namespace)
+		Expect(err).ShouldNot(HaveOccurred())
+		initContainers := gotJob.Spec.Template.Spec.InitContainers
+		Expect(initContainers).To(HaveLen(1))
+		Expect(initContainers[0].Name).To(Equal("test-sidecar"))
+		Expect(initContainers[0].RestartPolicy).NotTo(BeNil())
+		Expect(*initContainers[0].RestartPolicy).To(Equal(corev1.ContainerRestartPolicyAlways))
+		Expect(gotJob.Spec.Template.Spec.Containers).To(HaveLen(1))
+		// the sidecar is part of the job hash
+		Expect(j.GetHash()).NotTo(Equal(hashWithoutSidecar))
+		Expect(gotJob.Annotations["hash"]).To(Equal(j.GetHash()))
+	})
+
 	It("TTL can be updated after the job is finished", func() {
 		exampleJob := getExampleJob(namespace)
 		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash)
@@ -288,6 +393,159 @@ var _ = Describe("job package", func() {
 		var statusErr *k8s_errors.StatusError
 		Expect(errors.As(err, &statusErr)).To(BeTrue())
 		Expect(statusErr.Status().Message).To(ContainSubstring("Check job logs"))
+		var failedErr *job.FailedError
+		Expect(errors.As(err, &failedErr)).To(BeTrue())
+		Expect(failedErr.Reason).To(BeEmpty())
+		Expect(failedErr.PodName).To(BeEmpty())
+	})
+
+	It("reports the failure reason and the failed pod if the job failed", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash)
+
+		result, err := j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+
+		// there is no job controller in envtest so simulate the failed pod
+		// of the job
+		failedPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job-abcde",
+				Namespace: namespace,
+				Labels: map[string]string{
+					batchv1.JobNameLabel: exampleJob.Name,
+				},
+			},
+			Spec: exampleJob.Spec.Template.Spec,
+		}
+		Expect(cClient.Create(ctx, failedPod)).Should(Succeed())
+		failedPod.Status.Phase = corev1.PodFailed
+		Expect(cClient.Status().Update(ctx, failedPod)).Should(Succeed())
+
+		th.SimulateJobFailureWithReason(th.GetName(exampleJob), batchv1.JobReasonBackoffLimitExceeded)
+
+		_, err = j.DoJob(ctx, h)
+		Expect(err).Should(HaveOccurred())
+		var failedErr *job.FailedError
+		Expect(errors.As(err, &failedErr)).To(BeTrue())
+		Expect(failedErr.Reason).To(Equal(batchv1.JobReasonBackoffLimitExceeded))
+		Expect(failedErr.PodName).To(Equal(failedPod.Name))
+		Expect(j.GetFailureReason()).To(Equal(batchv1.JobReasonBackoffLimitExceeded))
+
+		var statusErr *k8s_errors.StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.Status().Message).To(
+			ContainSubstring("Job has reached the specified backoff limit"))
+		Expect(statusErr.Status().Message).To(ContainSubstring(failedPod.Name))
+	})
+
+	It("reports deadline exceeded if the job failed due to its active deadline", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash)
+
+		result, err := j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+
+		th.SimulateJobFailureWithReason(th.GetName(exampleJob), batchv1.JobReasonDeadlineExceeded)
+
+		_, err = j.DoJob(ctx, h)
+		Expect(err).Should(HaveOccurred())
+		var failedErr *job.FailedError
+		Expect(errors.As(err, &failedErr)).To(BeTrue())
+		Expect(failedErr.Reason).To(Equal(batchv1.JobReasonDeadlineExceeded))
+		Expect(failedErr.PodName).To(BeEmpty())
+		Expect(err.Error()).To(ContainSubstring("active deadline"))
+	})
+
+	It("restarts a failed job with the same hash if restart on failure is enabled", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash).EnableFailureRestart()
+
+		result, err := j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+		failedJob := th.GetJob(th.GetName(exampleJob))
+
+		th.SimulateJobFailureWithReason(th.GetName(exampleJob), batchv1.JobReasonBackoffLimitExceeded)
+
+		// the failed job is deleted and a requeue is requested
+		j = job.NewJob(getExampleJob(namespace), "test-job", !preserve, timeout, noHash).EnableFailureRestart()
+		result, err = j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+		Expect(j.GetHash()).To(Equal(failedJob.Annotations["hash"]))
+
+		// the next call creates the job again
+		result, err = j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+		Expect(th.GetJob(th.GetName(exampleJob)).UID).NotTo(Equal(failedJob.UID))
+	})
+
+	It("does not restart a failed job which is still retrying", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash).EnableFailureRestart()
+
+		result, err := j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+		retryingJob := th.GetJob(th.GetName(exampleJob))
+
+		// a failed pod without the JobFailed condition is within the backoff
+		th.SimulateJobFailure(th.GetName(exampleJob))
+
+		j = job.NewJob(getExampleJob(namespace), "test-job", !preserve, timeout, noHash).EnableFailureRestart()
+		result, err = j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+		Expect(th.GetJob(th.GetName(exampleJob)).UID).To(Equal(retryingJob.UID))
+	})
+
+	It("returns the logs of the failed pod of the job", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash)
+
+		result, err := j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+
+		// no failed pod yet is not an error
+		logs, err := job.GetJobLogs(ctx, h, exampleJob.Name, namespace, 10)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(logs).To(BeEmpty())
+
+		th.SimulateJobFailure(th.GetName(exampleJob))
+
+		// envtest has no kubelet to serve the pod logs so use a fake
+		// kclient holding the failed pod of the job
+		failedPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job-abcde",
+				Namespace: namespace,
+				Labels: map[string]string{
+					batchv1.JobNameLabel: exampleJob.Name,
+				},
+			},
+			Spec: exampleJob.Spec.Template.Spec,
+			Status: corev1.PodStatus{
+				Phase: corev1.PodFailed,
+			},
+		}
+		fakeHelper, err := helper.NewHelper(
+			h.GetBeforeObject(), cClient, k8sfake.NewSimpleClientset(failedPod), h.GetScheme(), ctrl.Log)
+		Expect(err).NotTo(HaveOccurred())
+
+		logs, err = job.GetJobLogs(ctx, fakeHelper, exampleJob.Name, namespace, 10)
+		Expect(err).ShouldNot(HaveOccurred())
+		// the fake clientset always returns "fake logs"
+		Expect(logs).To(Equal("fake logs"))
+
+		// pods of other jobs are ignored
+		logs, err = job.GetJobLogs(ctx, fakeHelper, "other-job", namespace, 10)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(logs).To(BeEmpty())
 	})
 
 	It("requeue if the job definition is changed while the old job still running and the wait for the old job to finish before re-run", func() {
@@ -389,6 +647,46 @@ var _ = Describe("job package", func() {
 		Expect(j.GetHash()).NotTo(Equal(oldHash))
 	})
 
+	It("waits for all completions of an indexed job", func() {
+		exampleJob := getExampleJob(namespace)
+		j := job.NewJob(exampleJob, "test-job", !preserve, timeout, noHash).
+			WithIndexedCompletion(3, 2)
+
+		result, err := j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+
+		k8sJob := th.GetJob(th.GetName(exampleJob))
+		Expect(k8sJob.Spec.CompletionMode).To(Equal(ptr.To(batchv1.IndexedCompletion)))
+		Expect(k8sJob.Spec.Completions).To(Equal(ptr.To[int32](3)))
+		Expect(k8sJob.Spec.Parallelism).To(Equal(ptr.To[int32](2)))
+
+		// Simulate that only some of the completions succeeded
+		Eventually(func(g Gomega) {
+			k8sJob := th.GetJob(th.GetName(exampleJob))
+			k8sJob.Status.Succeeded = 2
+			k8sJob.Status.Active = 0
+			g.Expect(cClient.Status().Update(ctx, k8sJob)).To(Succeed())
+		}, timeout, interval).Should(Succeed())
+
+		result, err = j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(requeue))
+
+		// Simulate that all completions succeeded
+		Eventually(func(g Gomega) {
+			k8sJob := th.GetJob(th.GetName(exampleJob))
+			k8sJob.Status.Succeeded = 3
+			k8sJob.Status.Active = 0
+			g.Expect(cClient.Status().Update(ctx, k8sJob)).To(Succeed())
+		}, timeout, interval).Should(Succeed())
+
+		result, err = j.DoJob(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(finished))
+		Expect(j.HasChanged()).To(BeTrue())
+	})
+
 	It("DeleteJob deletes existing jobs", func() {
 		_, k8sJob := runJobSuccessfully(namespace)
 		// the job exists
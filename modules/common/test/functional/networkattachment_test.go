@@ -0,0 +1,105 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package functional
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/networkattachment"
+
+	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("networkattachment package", func() {
+	var namespace string
+
+	BeforeEach(func() {
+		// NOTE(gibi): We need to create a unique namespace for each test run
+		// as namespaces cannot be deleted in a locally running envtest. See
+		// https://book.kubebuilder.io/reference/envtest.html#namespace-usage-limitation
+		namespace = uuid.New().String()
+		th.CreateNamespace(namespace)
+		// We still request the delete of the Namespace to properly cleanup if
+		// we run the test in an existing cluster.
+		DeferCleanup(th.DeleteNamespace, namespace)
+	})
+
+	It("reports the missing network attachments", func() {
+		th.CreateNetworkAttachmentDefinition(types.NamespacedName{Namespace: namespace, Name: "internalapi"})
+
+		missing, err := networkattachment.VerifyNetworkAttachments(
+			ctx, h, namespace, []string{"internalapi", "storage"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(missing).To(Equal([]string{"storage"}))
+	})
+
+	It("reports no missing network attachments if all exist", func() {
+		th.CreateNetworkAttachmentDefinition(types.NamespacedName{Namespace: namespace, Name: "internalapi"})
+		th.CreateNetworkAttachmentDefinition(types.NamespacedName{Namespace: namespace, Name: "storage"})
+
+		missing, err := networkattachment.VerifyNetworkAttachments(
+			ctx, h, namespace, []string{"internalapi", "storage"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(missing).To(BeEmpty())
+	})
+
+	It("reports the pods without IPs on the required networks", func() {
+		labels := map[string]string{"app": "test"}
+		createPod := func(name string, netStatus []networkv1.NetworkStatus) {
+			status, err := json.Marshal(netStatus)
+			Expect(err).ShouldNot(HaveOccurred())
+			p := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Namespace:   namespace,
+					Labels:      labels,
+					Annotations: map[string]string{networkv1.NetworkStatusAnnot: string(status)},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "test", Image: "test"}},
+				},
+			}
+			Expect(cClient.Create(ctx, p)).To(Succeed())
+		}
+
+		createPod("pod-0", []networkv1.NetworkStatus{
+			{Name: namespace + "/internalapi", IPs: []string{"172.17.0.10"}},
+			{Name: namespace + "/storage", IPs: []string{"172.18.0.10"}},
+		})
+		createPod("pod-1", []networkv1.NetworkStatus{
+			{Name: namespace + "/internalapi", IPs: []string{"172.17.0.11"}},
+			{Name: namespace + "/storage", IPs: []string{}},
+		})
+
+		ready, unsatisfied, err := networkattachment.VerifyNetworkStatusReady(
+			ctx, h, namespace, labels, []string{"internalapi", "storage"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(unsatisfied).To(Equal([]string{"pod-1/storage"}))
+
+		ready, unsatisfied, err = networkattachment.VerifyNetworkStatusReady(
+			ctx, h, namespace, labels, []string{"internalapi"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ready).To(BeTrue())
+		Expect(unsatisfied).To(BeEmpty())
+	})
+})
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package functional
+
+import (
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/pdb"
+
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("pdb package", func() {
+	var namespace string
+
+	BeforeEach(func() {
+		// NOTE(gibi): We need to create a unique namespace for each test run
+		// as namespaces cannot be deleted in a locally running envtest. See
+		// https://book.kubebuilder.io/reference/envtest.html#namespace-usage-limitation
+		namespace = uuid.New().String()
+		th.CreateNamespace(namespace)
+		// We still request the delete of the Namespace to properly cleanup if
+		// we run the test in an existing cluster.
+		DeferCleanup(th.DeleteNamespace, namespace)
+	})
+
+	It("creates and deletes a PodDisruptionBudget", func() {
+		p := pdb.NewPDB(
+			pdb.DefaultForReplicas("test-pdb", namespace, 3, map[string]string{"service": "test"}),
+			timeout,
+		)
+
+		result, err := p.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+
+		budget := &policyv1.PodDisruptionBudget{}
+		Expect(cClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "test-pdb"}, budget)).To(Succeed())
+		Expect(budget.Spec.MinAvailable.IntValue()).To(Equal(2))
+		Expect(budget.GetOwnerReferences()).To(HaveLen(1))
+		Expect(budget.GetOwnerReferences()[0]).To(HaveField("Name", h.GetBeforeObject().GetName()))
+
+		// there is no disruption controller in envtest updating the status
+		Expect(p.IsReady()).To(BeFalse())
+
+		Expect(p.Delete(ctx, h)).To(Succeed())
+		Eventually(func(g Gomega) {
+			err := cClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "test-pdb"}, budget)
+			g.Expect(err).To(HaveOccurred())
+		}, timeout, interval).Should(Succeed())
+	})
+
+	It("requeues until the PodDisruptionBudget is ready", func() {
+		p := pdb.NewPDB(
+			pdb.DefaultForReplicas("test-pdb", namespace, 3, map[string]string{"service": "test"}),
+			timeout,
+		)
+
+		result, err := p.CreateOrPatchAndWaitReady(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: timeout}))
+
+		th.SimulatePodDisruptionBudgetReady(types.NamespacedName{Namespace: namespace, Name: "test-pdb"}, 3)
+
+		result, err = p.CreateOrPatchAndWaitReady(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(p.IsReady()).To(BeTrue())
+		Expect(p.GetPDB().Status.DisruptionsAllowed).To(Equal(int32(1)))
+	})
+})
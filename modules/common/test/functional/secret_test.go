@@ -0,0 +1,315 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package functional
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func getExampleSecret(namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: namespace,
+			Labels: map[string]string{
+				"label": "a",
+			},
+			Annotations: map[string]string{
+				"anno": "a",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"key": []byte("value"),
+		},
+	}
+}
+
+var _ = Describe("secret package", func() {
+	var namespace string
+
+	BeforeEach(func() {
+		// NOTE(gibi): We need to create a unique namespace for each test run
+		// as namespaces cannot be deleted in a locally running envtest. See
+		// https://book.kubebuilder.io/reference/envtest.html#namespace-usage-limitation
+		namespace = uuid.New().String()
+		th.CreateNamespace(namespace)
+		// We still request the delete of the Namespace to properly cleanup if
+		// we run the test in an existing cluster.
+		DeferCleanup(th.DeleteNamespace, namespace)
+
+	})
+
+	It("creates secret with owner reference", func() {
+		s := secret.NewSecret(
+			getExampleSecret(namespace),
+			timeout,
+		)
+
+		result, err := s.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(s.GetHash()).NotTo(BeEmpty())
+
+		sec := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "test-secret"})
+		Expect(sec.Labels["label"]).To(Equal("a"))
+		Expect(sec.Annotations["anno"]).To(Equal("a"))
+		Expect(sec.Type).To(Equal(corev1.SecretTypeOpaque))
+		Expect(sec.Data["key"]).To(Equal([]byte("value")))
+		Expect(sec.GetOwnerReferences()).To(HaveLen(1))
+		Expect(sec.GetOwnerReferences()[0]).To(HaveField("Name", h.GetBeforeObject().GetName()))
+	})
+
+	It("patches the secret data and updates the hash", func() {
+		s := secret.NewSecret(
+			getExampleSecret(namespace),
+			timeout,
+		)
+
+		_, err := s.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		oldHash := s.GetHash()
+
+		newSecret := getExampleSecret(namespace)
+		newSecret.Data["key"] = []byte("new-value")
+		s = secret.NewSecret(newSecret, timeout)
+
+		_, err = s.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(s.GetHash()).NotTo(Equal(oldHash))
+
+		sec := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "test-secret"})
+		Expect(sec.Data["key"]).To(Equal([]byte("new-value")))
+	})
+
+	It("deletes the secret", func() {
+		s := secret.NewSecret(
+			getExampleSecret(namespace),
+			timeout,
+		)
+
+		_, err := s.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "test-secret"})
+
+		Expect(s.Delete(ctx, h)).To(Succeed())
+		th.AssertSecretDoesNotExist(types.NamespacedName{Namespace: namespace, Name: "test-secret"})
+
+		// deleting an already deleted secret is not an error
+		Expect(s.Delete(ctx, h)).To(Succeed())
+	})
+
+	It("creates an immutable secret and reports data changes", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		tmpl := util.Template{
+			Name:       "immutable-secret",
+			Namespace:  namespace,
+			Type:       util.TemplateTypeNone,
+			Immutable:  ptr.To(true),
+			CustomData: map[string]string{"key": "value"},
+		}
+		envVars := map[string]env.Setter{}
+
+		err := secret.EnsureSecrets(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		sec := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "immutable-secret"})
+		Expect(sec.Immutable).NotTo(BeNil())
+		Expect(*sec.Immutable).To(BeTrue())
+		Expect(sec.Data["key"]).To(Equal([]byte("value")))
+
+		// same data is not a change
+		err = secret.EnsureSecrets(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		tmpl.CustomData = map[string]string{"key": "new-value"}
+		err = secret.EnsureSecrets(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).Should(HaveOccurred())
+		Expect(errors.Is(err, secret.ErrSecretImmutable)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("rotate the secret"))
+
+		sec = th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "immutable-secret"})
+		Expect(sec.Data["key"]).To(Equal([]byte("value")))
+	})
+
+	It("sets the content hash as label on the secret", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		tmpl := util.Template{
+			Name:         "hashed-secret",
+			Namespace:    namespace,
+			Type:         util.TemplateTypeNone,
+			HashLabelKey: "config-hash",
+			CustomData:   map[string]string{"key": "value"},
+		}
+		envVars := map[string]env.Setter{}
+
+		err := secret.EnsureSecrets(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		sec := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "hashed-secret"})
+		hash, err := secret.Hash(&sec)
+		Expect(err).ShouldNot(HaveOccurred())
+		oldLabel := sec.Labels["config-hash"]
+		Expect(oldLabel).NotTo(BeEmpty())
+		Expect(hash).To(HavePrefix(oldLabel))
+		Expect(env.MergeEnvs(nil, envVars)).To(ContainElement(HaveField("Value", hash)))
+
+		tmpl.CustomData = map[string]string{"key": "new-value"}
+		err = secret.EnsureSecrets(ctx, h, owner, []util.Template{tmpl}, &envVars)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		sec = th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "hashed-secret"})
+		hash, err = secret.Hash(&sec)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sec.Labels["config-hash"]).NotTo(Equal(oldLabel))
+		Expect(hash).To(HavePrefix(sec.Labels["config-hash"]))
+	})
+
+	It("rotates a secret only when the content changes", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		tmpl := util.Template{
+			Name:       "rotated",
+			Namespace:  namespace,
+			Type:       util.TemplateTypeNone,
+			Immutable:  ptr.To(true),
+			CustomData: map[string]string{"key": "value"},
+		}
+		count := 0
+		suffix := func() string {
+			count++
+			return fmt.Sprintf("%d", count)
+		}
+
+		name, hash, err := secret.RotateSecret(ctx, h, owner, tmpl, suffix)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(name).To(Equal("rotated-1"))
+		Expect(hash).NotTo(BeEmpty())
+		sec := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: name})
+		Expect(sec.Labels).To(HaveKeyWithValue(secret.SecretRotationLabel, "rotated"))
+		Expect(sec.Annotations).To(HaveKeyWithValue(secret.SecretRotationGenerationAnnotation, "1"))
+
+		// same content returns the existing secret
+		sameName, sameHash, err := secret.RotateSecret(ctx, h, owner, tmpl, suffix)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sameName).To(Equal(name))
+		Expect(sameHash).To(Equal(hash))
+		Expect(count).To(Equal(1))
+
+		// changed content creates a new secret and keeps the old one
+		tmpl.CustomData = map[string]string{"key": "new-value"}
+		newName, newHash, err := secret.RotateSecret(ctx, h, owner, tmpl, suffix)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(newName).To(Equal("rotated-2"))
+		Expect(newHash).NotTo(Equal(hash))
+		sec = th.GetSecret(types.NamespacedName{Namespace: namespace, Name: newName})
+		Expect(sec.Data["key"]).To(Equal([]byte("new-value")))
+		Expect(sec.Annotations).To(HaveKeyWithValue(secret.SecretRotationGenerationAnnotation, "2"))
+		th.GetSecret(types.NamespacedName{Namespace: namespace, Name: name})
+
+		// the latest secret is found by generation, not by creation time or
+		// name, reverting the content again creates a new secret
+		tmpl.CustomData = map[string]string{"key": "value"}
+		revertName, _, err := secret.RotateSecret(ctx, h, owner, tmpl, func() string { return "0" })
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(revertName).To(Equal("rotated-0"))
+		sec = th.GetSecret(types.NamespacedName{Namespace: namespace, Name: revertName})
+		Expect(sec.Annotations).To(HaveKeyWithValue(secret.SecretRotationGenerationAnnotation, "3"))
+
+		sameName, _, err = secret.RotateSecret(ctx, h, owner, tmpl, suffix)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sameName).To(Equal(revertName))
+	})
+
+	It("adopts an unowned secret", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		secretName := types.NamespacedName{Namespace: namespace, Name: "byo-secret"}
+		th.CreateSecret(secretName, map[string][]byte{"key": []byte("value")})
+
+		Expect(secret.AdoptSecret(ctx, h, secretName.Name, namespace, owner)).To(Succeed())
+
+		sec := th.GetSecret(secretName)
+		Expect(sec.GetOwnerReferences()).To(HaveLen(1))
+		Expect(sec.GetOwnerReferences()[0]).To(HaveField("Name", "owner"))
+		Expect(sec.GetOwnerReferences()[0].Controller).To(Equal(ptr.To(true)))
+		Expect(sec.Data["key"]).To(Equal([]byte("value")))
+
+		// adopting again by the same owner is a no-op
+		Expect(secret.AdoptSecret(ctx, h, secretName.Name, namespace, owner)).To(Succeed())
+		sec = th.GetSecret(secretName)
+		Expect(sec.GetOwnerReferences()).To(HaveLen(1))
+
+		// a secret owned by someone else can't be adopted
+		other := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "other"}, map[string]interface{}{})
+		err := secret.AdoptSecret(ctx, h, secretName.Name, namespace, other)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("already owned"))
+	})
+
+	It("prunes the labeled secrets which are not kept", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		labels := map[string]string{"app": "rotated"}
+		for _, name := range []string{"secret-v1", "secret-v2", "secret-v3", "not-owned"} {
+			s := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels:    labels,
+				},
+			}
+			if name != "not-owned" {
+				Expect(controllerutil.SetControllerReference(owner, s, cClient.Scheme())).To(Succeed())
+			}
+			Expect(cClient.Create(ctx, s)).To(Succeed())
+		}
+		// not matching the labels
+		th.CreateSecret(types.NamespacedName{Namespace: namespace, Name: "other"}, map[string][]byte{})
+
+		err := secret.PruneSecretsWithLabel(ctx, h, owner, labels, []string{"secret-v3"})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		th.AssertSecretDoesNotExist(types.NamespacedName{Namespace: namespace, Name: "secret-v1"})
+		th.AssertSecretDoesNotExist(types.NamespacedName{Namespace: namespace, Name: "secret-v2"})
+		th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "secret-v3"})
+		th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "other"})
+		// not controlled by the owner
+		th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "not-owned"})
+
+		// nothing left to prune is a noop
+		err = secret.PruneSecretsWithLabel(ctx, h, owner, labels, []string{"secret-v3"})
+		Expect(err).ShouldNot(HaveOccurred())
+		th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "secret-v3"})
+
+		// empty labels are rejected
+		err = secret.PruneSecretsWithLabel(ctx, h, owner, nil, []string{"secret-v3"})
+		Expect(err).Should(HaveOccurred())
+		th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "not-owned"})
+	})
+})
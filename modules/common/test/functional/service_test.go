@@ -25,6 +25,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -146,6 +148,25 @@ var _ = Describe("service package", func() {
 
 	})
 
+	It("returns the operation result of the create or patch", func() {
+		s, err := service.NewService(
+			getExampleService(namespace, int32(80)),
+			timeout,
+			&service.OverrideSpec{},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		result, op, err := s.CreateOrPatchWithOp(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(op).To(Equal(controllerutil.OperationResultCreated))
+
+		s.AddAnnotation(map[string]string{"add": "bar"})
+		_, op, err = s.CreateOrPatchWithOp(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(op).To(Equal(controllerutil.OperationResultUpdated))
+	})
+
 	It("merges labels to the service", func() {
 		s, err := service.NewService(
 			getExampleService(namespace, int32(5000)),
@@ -238,4 +259,94 @@ var _ = Describe("service package", func() {
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(endpointURL).To(Equal(fmt.Sprintf("test-svc.%s.svc:80", namespace)))
 	})
+
+	It("reconciles a set of services and prunes the removed ones", func() {
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		svcLabels := map[string]string{"owner": "reconcile-test"}
+
+		desiredServices := func(names ...string) []*corev1.Service {
+			services := []*corev1.Service{}
+			for _, name := range names {
+				svc := getExampleService(namespace, int32(80))
+				svc.Name = name
+				services = append(services, svc)
+			}
+			return services
+		}
+
+		results, err := service.ReconcileServices(
+			ctx, h, owner, desiredServices("svc-a", "svc-b"), svcLabels)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results).To(HaveKeyWithValue("svc-a", ctrl.Result{}))
+		Expect(results).To(HaveKeyWithValue("svc-b", ctrl.Result{}))
+
+		svcA := th.AssertServiceExists(types.NamespacedName{Namespace: namespace, Name: "svc-a"})
+		Expect(svcA.Labels).To(HaveKeyWithValue("owner", "reconcile-test"))
+		Expect(svcA.Labels).To(HaveKeyWithValue("label", "a"))
+		Expect(svcA.GetOwnerReferences()).To(HaveLen(1))
+		Expect(svcA.GetOwnerReferences()[0].Name).To(Equal("owner"))
+		th.AssertServiceExists(types.NamespacedName{Namespace: namespace, Name: "svc-b"})
+
+		// a service without the labels is not pruned
+		unrelated := getExampleService(namespace, int32(80))
+		unrelated.Name = "unrelated"
+		s, err := service.NewService(unrelated, timeout, &service.OverrideSpec{})
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = s.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// a service with the labels, which is controlled by a different
+		// owner, is not pruned
+		otherOwned := getExampleService(namespace, int32(80))
+		otherOwned.Name = "other-owned"
+		otherOwned.Labels = map[string]string{"owner": "reconcile-test"}
+		s, err = service.NewService(otherOwned, timeout, &service.OverrideSpec{})
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = s.CreateOrPatch(ctx, h)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// svc-b is removed from the desired list, the svcLabels win over a
+		// conflicting label of the desired service
+		desired := desiredServices("svc-a")
+		desired[0].Labels["owner"] = "conflicting"
+		results, err = service.ReconcileServices(
+			ctx, h, owner, desired, svcLabels)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(desired[0].Labels).To(HaveKeyWithValue("owner", "conflicting"))
+		svcA = th.AssertServiceExists(types.NamespacedName{Namespace: namespace, Name: "svc-a"})
+		Expect(svcA.Labels).To(HaveKeyWithValue("owner", "reconcile-test"))
+		th.AssertServiceDoesNotExist(types.NamespacedName{Namespace: namespace, Name: "svc-b"})
+		th.AssertServiceExists(types.NamespacedName{Namespace: namespace, Name: "unrelated"})
+		th.AssertServiceExists(types.NamespacedName{Namespace: namespace, Name: "other-owned"})
+
+		// empty labels are rejected
+		_, err = service.ReconcileServices(ctx, h, owner, desiredServices("svc-a"), nil)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("deletes only the services matching the labels", func() {
+		for name, labelValue := range map[string]string{
+			"svc-match-1": "delete",
+			"svc-match-2": "delete",
+			"svc-keep":    "keep",
+		} {
+			svc := getExampleService(namespace, int32(80))
+			svc.Name = name
+			svc.Labels["cleanup"] = labelValue
+			s, err := service.NewService(svc, timeout, &service.OverrideSpec{})
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = s.CreateOrPatch(ctx, h)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		owner := th.CreateConfigMap(types.NamespacedName{Namespace: namespace, Name: "owner"}, map[string]interface{}{})
+		err := service.DeleteServicesWithLabel(ctx, h, owner, map[string]string{"cleanup": "delete"})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		th.AssertServiceDoesNotExist(types.NamespacedName{Namespace: namespace, Name: "svc-match-1"})
+		th.AssertServiceDoesNotExist(types.NamespacedName{Namespace: namespace, Name: "svc-match-2"})
+		th.AssertServiceExists(types.NamespacedName{Namespace: namespace, Name: "svc-keep"})
+	})
 })
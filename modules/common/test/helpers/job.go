@@ -20,6 +20,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // GetJob retrieves a specified Job resource from the cluster.
@@ -67,6 +69,33 @@ func (tc *TestHelper) SimulateJobFailure(name types.NamespacedName) {
 	tc.Logger.Info("Simulated Job failure", "on", name)
 }
 
+// SimulateJobFailureWithReason retrieves the Job and simulates the failure of
+// a Kubernetes Job resource with a JobFailed condition having the given reason,
+// e.g. batchv1.JobReasonBackoffLimitExceeded.
+//
+// Example usage:
+//
+//	th.SimulateJobFailureWithReason(types.NamespacedName{Name: "test-job", Namespace: "default"}, batchv1.JobReasonDeadlineExceeded)
+func (tc *TestHelper) SimulateJobFailureWithReason(name types.NamespacedName, reason string) {
+	gomega.Eventually(func(g gomega.Gomega) {
+		job := tc.GetJob(name)
+
+		// Simulate that the job is failed with the given reason
+		job.Status.Failed = 1
+		job.Status.Active = 0
+		job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+			Type:               batchv1.JobFailed,
+			Status:             corev1.ConditionTrue,
+			Reason:             reason,
+			LastProbeTime:      metav1.Now(),
+			LastTransitionTime: metav1.Now(),
+		})
+		g.Expect(tc.K8sClient.Status().Update(tc.Ctx, job)).To(gomega.Succeed())
+
+	}, tc.Timeout, tc.Interval).Should(gomega.Succeed())
+	tc.Logger.Info("Simulated Job failure", "on", name, "reason", reason)
+}
+
 // SimulateJobSuccess retrieves the Job and simulates the success of a Kubernetes Job resource.
 //
 // Note: In a real environment where the mariadb-operator is deployed, this
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 Red Hat
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"github.com/onsi/gomega"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// GetPodDisruptionBudget - retrieves a PodDisruptionBudget resource from cluster.
+//
+// Example usage:
+//
+//	pdb := th.GetPodDisruptionBudget(types.NamespacedName{Namespace: namespace, Name: "test-pdb"})
+func (tc *TestHelper) GetPodDisruptionBudget(name types.NamespacedName) *policyv1.PodDisruptionBudget {
+	pdb := &policyv1.PodDisruptionBudget{}
+	gomega.Eventually(func(g gomega.Gomega) {
+		g.Expect(tc.K8sClient.Get(tc.Ctx, name, pdb)).Should(gomega.Succeed())
+	}, tc.Timeout, tc.Interval).Should(gomega.Succeed())
+
+	return pdb
+}
+
+// SimulatePodDisruptionBudgetReady function retrieves the PodDisruptionBudget
+// resource and simulates that the disruption controller processed it and found
+// the expected number of healthy pods, as there is no disruption controller in
+// envtest.
+//
+// Example usage:
+//
+//	th.SimulatePodDisruptionBudgetReady(types.NamespacedName{Namespace: namespace, Name: "test-pdb"}, 3)
+func (tc *TestHelper) SimulatePodDisruptionBudgetReady(name types.NamespacedName, expectedPods int32) {
+	gomega.Eventually(func(g gomega.Gomega) {
+		pdb := tc.GetPodDisruptionBudget(name)
+
+		desiredHealthy := expectedPods
+		if pdb.Spec.MinAvailable != nil {
+			desiredHealthy = int32(pdb.Spec.MinAvailable.IntValue())
+		}
+		disruptionsAllowed := expectedPods - desiredHealthy
+		if disruptionsAllowed < 0 {
+			disruptionsAllowed = 0
+		}
+
+		pdb.Status.ObservedGeneration = pdb.Generation
+		pdb.Status.ExpectedPods = expectedPods
+		pdb.Status.CurrentHealthy = expectedPods
+		pdb.Status.DesiredHealthy = desiredHealthy
+		pdb.Status.DisruptionsAllowed = disruptionsAllowed
+		meta.SetStatusCondition(&pdb.Status.Conditions, metav1.Condition{
+			Type:               policyv1.DisruptionAllowedCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             policyv1.SufficientPodsReason,
+			ObservedGeneration: pdb.Generation,
+		})
+		g.Expect(tc.K8sClient.Status().Update(tc.Ctx, pdb)).To(gomega.Succeed())
+	}, tc.Timeout, tc.Interval).Should(gomega.Succeed())
+
+	tc.Logger.Info("Simulated PodDisruptionBudget ready", "on", name)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"bytes"
+	"context"
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// KeystoreSecretSuffix - suffix of the name of the secret holding the keystore
+// created by CreateKeystoreFromCertSecret
+const KeystoreSecretSuffix = "-keystore"
+
+// CreateKeystoreFromCertSecret - creates or patches the secret
+// <secretName>-keystore, owned by the object being reconciled, holding a
+// PKCS12 keystore under keystoreKey. The keystore is protected by password and
+// contains the tls.crt, tls.key and, if present, the ca.crt of secretName.
+// The keystore is only re-created if the certificates changed or the keystore
+// can not be opened with password anymore, as the encoding uses a random
+// salt and would otherwise change on every call.
+func CreateKeystoreFromCertSecret(
+	ctx context.Context,
+	h *helper.Helper,
+	secretName types.NamespacedName,
+	password string,
+	keystoreKey string,
+) (*corev1.Secret, error) {
+	certSecret, _, err := secret.GetSecret(ctx, h, secretName.Name, secretName.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []string{CertKey, PrivateKey} {
+		if _, ok := certSecret.Data[field]; !ok {
+			return nil, fmt.Errorf("field %s not found in Secret %s", field, secretName)
+		}
+	}
+
+	keystoreSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName.Name + KeystoreSecretSuffix,
+			Namespace: secretName.Namespace,
+		},
+	}
+
+	_, err = controllerutil.CreateOrPatch(ctx, h.GetClient(), keystoreSecret, func() error {
+		if !keystoreMatches(keystoreSecret.Data[keystoreKey], password, certSecret.Data[CertKey], certSecret.Data[CAKey]) {
+			keystore, err := createPKCS12Keystore(
+				certSecret.Data[CertKey], certSecret.Data[PrivateKey], certSecret.Data[CAKey], password)
+			if err != nil {
+				return err
+			}
+			if keystoreSecret.Data == nil {
+				keystoreSecret.Data = map[string][]byte{}
+			}
+			keystoreSecret.Data[keystoreKey] = keystore
+		}
+
+		return controllerutil.SetControllerReference(h.GetBeforeObject(), keystoreSecret, h.GetScheme())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating keystore from Secret %s: %w", secretName, err)
+	}
+
+	return keystoreSecret, nil
+}
+
+// createPKCS12Keystore - returns a PKCS12 keystore protected by password
+// holding the private key keyPEM, the certificate certPEM and the CA
+// certificates in caPEM
+func createPKCS12Keystore(certPEM []byte, keyPEM []byte, caPEM []byte, password string) ([]byte, error) {
+	keyPair, err := cryptotls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%s and %s are not a valid pair: %w", CertKey, PrivateKey, err)
+	}
+
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	caCerts := []*x509.Certificate{}
+	for block, rest := pem.Decode(caPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		caCert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", CAKey, err)
+		}
+		caCerts = append(caCerts, caCert)
+	}
+
+	return pkcs12.Modern.Encode(keyPair.PrivateKey, cert, caCerts, password)
+}
+
+// keystoreMatches - returns true if keystore can be opened with password and
+// holds the first certificate of certPEM and the CA certificates of caPEM
+func keystoreMatches(keystore []byte, password string, certPEM []byte, caPEM []byte) bool {
+	if len(keystore) == 0 {
+		return false
+	}
+
+	_, cert, caCerts, err := pkcs12.DecodeChain(keystore, password)
+	if err != nil {
+		return false
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil || !bytes.Equal(cert.Raw, block.Bytes) {
+		return false
+	}
+
+	i := 0
+	for block, rest := pem.Decode(caPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if i >= len(caCerts) || !bytes.Equal(caCerts[i].Raw, block.Bytes) {
+			return false
+		}
+		i++
+	}
+
+	return i == len(caCerts)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"encoding/pem"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+func TestCreatePKCS12Keystore(t *testing.T) {
+	g := NewWithT(t)
+
+	certPEM, keyPEM := generateCertKeyPair(t)
+	caPEM, _ := generateCertKeyPair(t)
+
+	keystore, err := createPKCS12Keystore(certPEM, keyPEM, caPEM, "secret")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	key, cert, caCerts, err := pkcs12.DecodeChain(keystore, "secret")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(key).ToNot(BeNil())
+	certBlock, _ := pem.Decode(certPEM)
+	g.Expect(cert.Raw).To(Equal(certBlock.Bytes))
+	g.Expect(caCerts).To(HaveLen(1))
+	caBlock, _ := pem.Decode(caPEM)
+	g.Expect(caCerts[0].Raw).To(Equal(caBlock.Bytes))
+
+	// the keystore can not be opened with another password
+	_, _, _, err = pkcs12.DecodeChain(keystore, "wrong")
+	g.Expect(err).To(HaveOccurred())
+
+	// a key not matching the cert is rejected
+	_, otherKeyPEM := generateCertKeyPair(t)
+	_, err = createPKCS12Keystore(certPEM, otherKeyPEM, nil, "secret")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("not a valid pair"))
+}
+
+func TestKeystoreMatches(t *testing.T) {
+	g := NewWithT(t)
+
+	certPEM, keyPEM := generateCertKeyPair(t)
+	caPEM, _ := generateCertKeyPair(t)
+	otherCertPEM, _ := generateCertKeyPair(t)
+
+	keystore, err := createPKCS12Keystore(certPEM, keyPEM, caPEM, "secret")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(keystoreMatches(keystore, "secret", certPEM, caPEM)).To(BeTrue())
+	g.Expect(keystoreMatches(nil, "secret", certPEM, caPEM)).To(BeFalse())
+	g.Expect(keystoreMatches(keystore, "wrong", certPEM, caPEM)).To(BeFalse())
+	g.Expect(keystoreMatches(keystore, "secret", otherCertPEM, caPEM)).To(BeFalse())
+	g.Expect(keystoreMatches(keystore, "secret", certPEM, nil)).To(BeFalse())
+	g.Expect(keystoreMatches(keystore, "secret", certPEM, append(caPEM, otherCertPEM...))).To(BeFalse())
+}
@@ -19,8 +19,12 @@ limitations under the License.
 package tls
 
 import (
+	"bytes"
 	"context"
+	cryptotls "crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"time"
 
@@ -65,6 +69,8 @@ const (
 	DefaultCertMountDir = "/var/lib/config-data/tls/certs"
 	// DefaultKeyMountDir - updated default path to mount cert keys inside container
 	DefaultKeyMountDir = "/var/lib/config-data/tls/private"
+	// DefaultClientCertMountDir - default path to mount client certs and keys for mutual TLS inside container
+	DefaultClientCertMountDir = "/var/lib/config-data/tls/client"
 
 	// TLSHashName - Name of the hash of hashes of all cert resources used to identify a change
 	TLSHashName = "certs"
@@ -75,6 +81,17 @@ const (
 
 	// DefaultClusterInternalDomain - cluster internal dns domain
 	DefaultClusterInternalDomain = "cluster.local"
+
+	// CABundleInitContainerName - name of the init container merging the CA bundle into the system trust
+	CABundleInitContainerName = "ca-bundle-init"
+	// CABundleInitMountDir - path the CA bundle secret gets mounted to in the init container
+	CABundleInitMountDir = "/var/lib/config-data/ca-bundle"
+	// CATrustVolume - name of the emptyDir volume holding the extracted system trust
+	CATrustVolume = "ca-trust-extracted"
+	// CATrustExtractedDir - path of the extracted system trust, shared with the service containers
+	CATrustExtractedDir = "/etc/pki/ca-trust/extracted"
+	// CATrustAnchorsDir - path for additional CA certs to be added to the system trust
+	CATrustAnchorsDir = "/etc/pki/ca-trust/source/anchors"
 )
 
 // SimpleService defines the observed state of TLS for a single service
@@ -254,7 +271,7 @@ func ValidateCACertSecret(
 		caSecret,
 		[]string{CABundleKey},
 		c,
-		5*time.Second)
+		helper.ShortRequeue)
 	if err != nil {
 		return "", err
 	} else if (ctrlResult != ctrl.Result{}) {
@@ -268,6 +285,7 @@ func ValidateCACertSecret(
 }
 
 // ValidateCertSecret - validates the content of the cert secret to make sure "tls.key", "tls.crt" and optional "ca.crt" keys exist
+// and the private key in "tls.key" matches the certificate in "tls.crt"
 func (s *Service) ValidateCertSecret(ctx context.Context, h *helper.Helper, namespace string) (string, error) {
 	// define keys to expect in cert secret
 	keys := []string{PrivateKey, CertKey}
@@ -275,24 +293,130 @@ func (s *Service) ValidateCertSecret(ctx context.Context, h *helper.Helper, name
 		keys = append(keys, CAKey)
 	}
 
-	hash, ctrlResult, err := secret.VerifySecret(
-		ctx,
-		types.NamespacedName{Name: s.SecretName, Namespace: namespace},
-		keys,
-		h.GetClient(),
-		5*time.Second)
+	// read the secret once, so the hash and the validated pair match
+	certSecret, _, err := secret.GetSecret(ctx, h, s.SecretName, namespace)
 	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return "", k8s_errors.NewNotFound(
+				corev1.Resource(corev1.ResourceSecrets.String()),
+				fmt.Sprintf("%s in namespace %s", s.SecretName, namespace),
+			)
+		}
 		return "", err
-	} else if (ctrlResult != ctrl.Result{}) {
-		return "", k8s_errors.NewNotFound(
-			corev1.Resource(corev1.ResourceSecrets.String()),
-			fmt.Sprintf("%s in namespace %s", s.SecretName, namespace),
-		)
+	}
+
+	hash, err := secret.HashFields(certSecret, keys)
+	if err != nil {
+		return "", err
+	}
+
+	err = validateCertKeyPair(certSecret.Data[CertKey], certSecret.Data[PrivateKey])
+	if err != nil {
+		return "", fmt.Errorf("invalid cert secret %s in namespace %s: %w", s.SecretName, namespace, err)
 	}
 
 	return hash, nil
 }
 
+// validateCertKeyPair - validates that certPEM and keyPEM can be parsed and
+// the private key matches the public key of the certificate
+func validateCertKeyPair(certPEM []byte, keyPEM []byte) error {
+	_, err := cryptotls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("%s and %s are not a valid pair: %w", CertKey, PrivateKey, err)
+	}
+
+	return nil
+}
+
+// ParseCertSANs - returns the DNS names and IP addresses of the subject
+// alternative names of the first certificate in certPEM
+func ParseCertSANs(certPEM []byte) ([]string, []string, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ipAddresses := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ipAddresses = append(ipAddresses, ip.String())
+	}
+
+	return append([]string{}, cert.DNSNames...), ipAddresses, nil
+}
+
+// ParseCABundle - returns the certificates of the PEM encoded CA bundle in
+// the order they appear in the bundle. PEM blocks which are no certificates
+// are skipped.
+func ParseCABundle(pemData []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+
+	rest := pemData
+	for len(bytes.TrimSpace(rest)) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("CA bundle contains invalid PEM data")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("CA bundle contains an invalid certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+// FilterExpiredCAs - partitions certs into the ones which are still valid at
+// now and the ones which expired, keeping the order of certs. Certificates
+// which are not yet valid are considered valid, as a new CA might be added to
+// the bundle before it gets used.
+func FilterExpiredCAs(certs []*x509.Certificate, now time.Time) (valid, expired []*x509.Certificate) {
+	valid = []*x509.Certificate{}
+	expired = []*x509.Certificate{}
+	for _, cert := range certs {
+		if now.After(cert.NotAfter) {
+			expired = append(expired, cert)
+		} else {
+			valid = append(valid, cert)
+		}
+	}
+
+	return valid, expired
+}
+
+// CABundleSecretData - returns the data of the CABundleSecret. The
+// InternalCABundleKey holds the internalCA, the CABundleKey the full bundle of
+// the internalCA followed by the additional caBundles, e.g. the system CAs.
+// The PEM blocks get validated and CAs contained in multiple bundles are only
+// added once.
+func CABundleSecretData(internalCA []byte, caBundles ...[]byte) (map[string][]byte, error) {
+	internal, err := util.ConcatPEM([][]byte{internalCA})
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal CA: %w", err)
+	}
+
+	bundle, err := util.ConcatPEM(append([][]byte{internal}, caBundles...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA bundle: %w", err)
+	}
+
+	return map[string][]byte{
+		CABundleKey:         bundle,
+		InternalCABundleKey: internal,
+	}, nil
+}
+
 // ValidateEndpointCerts - validates all services from an endpointCfgs and
 // returns the hash of hashes for all the certificates
 func ValidateEndpointCerts(
@@ -381,6 +505,33 @@ func (s *Service) CreateVolumeMounts(serviceID string) []corev1.VolumeMount {
 	return volumeMounts
 }
 
+// CreateClientVolumeMounts - add volume mounts for using the TLS cert and key
+// of the service as client credentials for mutual TLS, e.g. if the cert got
+// requested with ClientAuth. They get mounted to
+// DefaultClientCertMountDir/<service id>.crt and .key from the volume returned
+// by CreateVolume.
+func (s *Service) CreateClientVolumeMounts(serviceID string) []corev1.VolumeMount {
+	volumeMounts := []corev1.VolumeMount{}
+	if serviceID == "" {
+		serviceID = "default"
+	}
+	if s.SecretName != "" {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      serviceID + "-tls-certs",
+			MountPath: fmt.Sprintf("%s/%s.crt", DefaultClientCertMountDir, serviceID),
+			SubPath:   CertKey,
+			ReadOnly:  true,
+		}, corev1.VolumeMount{
+			Name:      serviceID + "-tls-certs",
+			MountPath: fmt.Sprintf("%s/%s.key", DefaultClientCertMountDir, serviceID),
+			SubPath:   PrivateKey,
+			ReadOnly:  true,
+		})
+	}
+
+	return volumeMounts
+}
+
 // CreateVolume - add volume for TLS certificates and CA certificate for the service
 func (s *Service) CreateVolume(serviceID string) corev1.Volume {
 	volume := corev1.Volume{}
@@ -402,6 +553,63 @@ func (s *Service) CreateVolume(serviceID string) corev1.Volume {
 	return volume
 }
 
+// CreateProjectedVolume - add a single projected volume for the TLS cert and
+// key of the service, the CA cert if CaMount is set, and the CA bundle of ca.
+// All files get the same 0400 mode. The files are available under their secret
+// key, e.g. tls.crt, tls.key, ca.crt and tls-ca-bundle.pem, to be used as
+// SubPath of the volume mounts.
+func (s *Service) CreateProjectedVolume(serviceID string, ca *Ca) corev1.Volume {
+	volume := corev1.Volume{}
+	if serviceID == "" {
+		serviceID = "default"
+	}
+
+	sources := []corev1.VolumeProjection{}
+	if s.SecretName != "" {
+		items := []corev1.KeyToPath{
+			{Key: CertKey, Path: CertKey},
+			{Key: PrivateKey, Path: PrivateKey},
+		}
+		if s.CaMount != nil {
+			items = append(items, corev1.KeyToPath{Key: CAKey, Path: CAKey})
+		}
+		sources = append(sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: s.SecretName,
+				},
+				Items: items,
+			},
+		})
+	}
+	if ca != nil && ca.CaBundleSecretName != "" {
+		sources = append(sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: ca.CaBundleSecretName,
+				},
+				Items: []corev1.KeyToPath{
+					{Key: CABundleKey, Path: CABundleKey},
+				},
+			},
+		})
+	}
+
+	if len(sources) > 0 {
+		volume = corev1.Volume{
+			Name: serviceID + "-tls-projected",
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources:     sources,
+					DefaultMode: ptr.To[int32](0400),
+				},
+			},
+		}
+	}
+
+	return volume
+}
+
 // CreateVolumeMounts creates volume mounts for CA bundle file
 func (c *Ca) CreateVolumeMounts(caBundleMount *string) []corev1.VolumeMount {
 	volumeMounts := []corev1.VolumeMount{}
@@ -442,3 +650,69 @@ func (c *Ca) CreateVolume() corev1.Volume {
 
 	return volume
 }
+
+// CABundleInitContainers - returns the init container which merges the CA
+// bundle from caSecretName into the system trust of the image, or an empty
+// slice if caSecretName is empty, so the result can be appended to the init
+// containers of a pod. The extracted trust is written to the CATrustVolume,
+// which should be mounted to CATrustExtractedDir in the service containers.
+// The pod requires the volumes returned by CABundleInitVolumes.
+//
+// The container runs as root, as CATrustAnchorsDir and the files
+// update-ca-trust reads are owned by root in the images. It only runs the
+// trust extraction before the service containers start.
+func CABundleInitContainers(image string, caSecretName string) []corev1.Container {
+	if caSecretName == "" {
+		return []corev1.Container{}
+	}
+
+	return []corev1.Container{
+		{
+			Name:  CABundleInitContainerName,
+			Image: image,
+			Command: []string{
+				"/bin/bash",
+			},
+			Args: []string{
+				"-c",
+				// update-ca-trust extract does not create the directories it
+				// writes to in the empty CATrustVolume
+				fmt.Sprintf("cp %s/%s %s/ && mkdir -p %[4]s/pem %[4]s/openssl %[4]s/java %[4]s/edk2 && update-ca-trust extract",
+					CABundleInitMountDir, CABundleKey, CATrustAnchorsDir, CATrustExtractedDir),
+			},
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser: ptr.To[int64](0),
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      CABundleLabel,
+					MountPath: CABundleInitMountDir,
+					ReadOnly:  true,
+				},
+				{
+					Name:      CATrustVolume,
+					MountPath: CATrustExtractedDir,
+				},
+			},
+		},
+	}
+}
+
+// CABundleInitVolumes - returns the volumes required by CABundleInitContainers
+func CABundleInitVolumes(caSecretName string) []corev1.Volume {
+	if caSecretName == "" {
+		return []corev1.Volume{}
+	}
+
+	ca := Ca{CaBundleSecretName: caSecretName}
+
+	return []corev1.Volume{
+		ca.CreateVolume(),
+		{
+			Name: CATrustVolume,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+}
@@ -17,7 +17,16 @@ limitations under the License.
 package tls
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
@@ -201,6 +210,68 @@ func TestServiceCreateVolumeMounts(t *testing.T) {
 	}
 }
 
+func TestServiceCreateClientVolumeMounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		service *Service
+		id      string
+		want    []corev1.VolumeMount
+	}{
+		{
+			name:    "No TLS Secret",
+			service: &Service{},
+			id:      "foo",
+			want:    []corev1.VolumeMount{},
+		},
+		{
+			name:    "TLS Secret",
+			service: &Service{SecretName: "cert-secret", CertMount: ptr.To("/mount/my/cert.crt")},
+			id:      "foo",
+			want: []corev1.VolumeMount{
+				{
+					MountPath: "/var/lib/config-data/tls/client/foo.crt",
+					Name:      "foo-tls-certs",
+					ReadOnly:  true,
+					SubPath:   "tls.crt",
+				},
+				{
+					MountPath: "/var/lib/config-data/tls/client/foo.key",
+					Name:      "foo-tls-certs",
+					ReadOnly:  true,
+					SubPath:   "tls.key",
+				},
+			},
+		},
+		{
+			name:    "TLS Secret no serviceID",
+			service: &Service{SecretName: "cert-secret"},
+			want: []corev1.VolumeMount{
+				{
+					MountPath: "/var/lib/config-data/tls/client/default.crt",
+					Name:      "default-tls-certs",
+					ReadOnly:  true,
+					SubPath:   "tls.crt",
+				},
+				{
+					MountPath: "/var/lib/config-data/tls/client/default.key",
+					Name:      "default-tls-certs",
+					ReadOnly:  true,
+					SubPath:   "tls.key",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mounts := tt.service.CreateClientVolumeMounts(tt.id)
+			g.Expect(mounts).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestServiceCreateVolume(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -252,6 +323,105 @@ func TestServiceCreateVolume(t *testing.T) {
 	}
 }
 
+func TestServiceCreateProjectedVolume(t *testing.T) {
+	certSource := corev1.VolumeProjection{
+		Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "cert-secret"},
+			Items: []corev1.KeyToPath{
+				{Key: "tls.crt", Path: "tls.crt"},
+				{Key: "tls.key", Path: "tls.key"},
+			},
+		},
+	}
+	bundleSource := corev1.VolumeProjection{
+		Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "ca-bundle"},
+			Items: []corev1.KeyToPath{
+				{Key: "tls-ca-bundle.pem", Path: "tls-ca-bundle.pem"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		service *Service
+		ca      *Ca
+		id      string
+		want    corev1.Volume
+	}{
+		{
+			name:    "No Secrets",
+			service: &Service{},
+			ca:      &Ca{},
+			want:    corev1.Volume{},
+		},
+		{
+			name:    "TLS Secret and CA bundle",
+			service: &Service{SecretName: "cert-secret"},
+			ca:      &Ca{CaBundleSecretName: "ca-bundle"},
+			id:      "foo",
+			want: corev1.Volume{
+				Name: "foo-tls-projected",
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources:     []corev1.VolumeProjection{certSource, bundleSource},
+						DefaultMode: ptr.To[int32](0400),
+					},
+				},
+			},
+		},
+		{
+			name:    "TLS Secret with CA cert and no CA bundle",
+			service: &Service{SecretName: "cert-secret", CaMount: ptr.To("/mount/my/ca.crt")},
+			ca:      nil,
+			want: corev1.Volume{
+				Name: "default-tls-projected",
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources: []corev1.VolumeProjection{
+							{
+								Secret: &corev1.SecretProjection{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "cert-secret"},
+									Items: []corev1.KeyToPath{
+										{Key: "tls.crt", Path: "tls.crt"},
+										{Key: "tls.key", Path: "tls.key"},
+										{Key: "ca.crt", Path: "ca.crt"},
+									},
+								},
+							},
+						},
+						DefaultMode: ptr.To[int32](0400),
+					},
+				},
+			},
+		},
+		{
+			name:    "Only CA bundle",
+			service: &Service{},
+			ca:      &Ca{CaBundleSecretName: "ca-bundle"},
+			id:      "foo",
+			want: corev1.Volume{
+				Name: "foo-tls-projected",
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources:     []corev1.VolumeProjection{bundleSource},
+						DefaultMode: ptr.To[int32](0400),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			volume := tt.service.CreateProjectedVolume(tt.id, tt.ca)
+			g.Expect(volume).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestCACreateVolumeMounts(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -343,3 +513,312 @@ func TestCaCreateVolume(t *testing.T) {
 		})
 	}
 }
+
+func TestCABundleInitContainers(t *testing.T) {
+	tests := []struct {
+		name         string
+		caSecretName string
+		want         []corev1.Container
+	}{
+		{
+			name:         "No CA secret",
+			caSecretName: "",
+			want:         []corev1.Container{},
+		},
+		{
+			name:         "CA secret",
+			caSecretName: "ca-secret",
+			want: []corev1.Container{
+				{
+					Name:    "ca-bundle-init",
+					Image:   "test-image",
+					Command: []string{"/bin/bash"},
+					Args: []string{
+						"-c",
+						"cp /var/lib/config-data/ca-bundle/tls-ca-bundle.pem /etc/pki/ca-trust/source/anchors/ && " +
+							"mkdir -p /etc/pki/ca-trust/extracted/pem /etc/pki/ca-trust/extracted/openssl " +
+							"/etc/pki/ca-trust/extracted/java /etc/pki/ca-trust/extracted/edk2 && update-ca-trust extract",
+					},
+					SecurityContext: &corev1.SecurityContext{
+						RunAsUser: ptr.To[int64](0),
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "combined-ca-bundle",
+							MountPath: "/var/lib/config-data/ca-bundle",
+							ReadOnly:  true,
+						},
+						{
+							Name:      "ca-trust-extracted",
+							MountPath: "/etc/pki/ca-trust/extracted",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := CABundleInitContainers("test-image", tt.caSecretName)
+			g.Expect(c).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestCABundleInitVolumes(t *testing.T) {
+	tests := []struct {
+		name         string
+		caSecretName string
+		want         []corev1.Volume
+	}{
+		{
+			name:         "No CA secret",
+			caSecretName: "",
+			want:         []corev1.Volume{},
+		},
+		{
+			name:         "CA secret",
+			caSecretName: "ca-secret",
+			want: []corev1.Volume{
+				{
+					Name: "combined-ca-bundle",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName:  "ca-secret",
+							DefaultMode: ptr.To[int32](0444),
+						},
+					},
+				},
+				{
+					Name: "ca-trust-extracted",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			volumes := CABundleInitVolumes(tt.caSecretName)
+			g.Expect(volumes).To(Equal(tt.want))
+		})
+	}
+}
+
+// generateCertKeyPair - returns a PEM encoded self signed certificate and
+// its private key
+func generateCertKeyPair(t *testing.T) ([]byte, []byte) {
+	g := NewWithT(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-svc"},
+		DNSNames:     []string{"test-svc"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestParseCertSANs(t *testing.T) {
+	g := NewWithT(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "keystone-internal"},
+		DNSNames: []string{
+			"keystone-internal.openstack.svc",
+			"keystone-internal.openstack.svc.cluster.local",
+		},
+		IPAddresses: []net.IP{
+			net.ParseIP("172.17.0.80"),
+			net.ParseIP("fd00:bbbb::80"),
+		},
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	g.Expect(err).ToNot(HaveOccurred())
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	dnsNames, ipAddresses, err := ParseCertSANs(certPEM)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(dnsNames).To(Equal([]string{
+		"keystone-internal.openstack.svc",
+		"keystone-internal.openstack.svc.cluster.local",
+	}))
+	g.Expect(ipAddresses).To(Equal([]string{"172.17.0.80", "fd00:bbbb::80"}))
+
+	// cert with a DNS name only
+	cert, _ := generateCertKeyPair(t)
+	dnsNames, ipAddresses, err = ParseCertSANs(cert)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(dnsNames).To(Equal([]string{"test-svc"}))
+	g.Expect(ipAddresses).To(BeEmpty())
+
+	// invalid cert
+	_, _, err = ParseCertSANs([]byte("cert"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestValidateCertKeyPair(t *testing.T) {
+	cert, key := generateCertKeyPair(t)
+	_, otherKey := generateCertKeyPair(t)
+
+	tests := []struct {
+		name    string
+		cert    []byte
+		key     []byte
+		wantErr bool
+	}{
+		{
+			name:    "matching cert and key",
+			cert:    cert,
+			key:     key,
+			wantErr: false,
+		},
+		{
+			name:    "mismatched cert and key",
+			cert:    cert,
+			key:     otherKey,
+			wantErr: true,
+		},
+		{
+			name:    "invalid cert",
+			cert:    []byte("cert"),
+			key:     key,
+			wantErr: true,
+		},
+		{
+			name:    "invalid key",
+			cert:    cert,
+			key:     []byte("key"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := validateCertKeyPair(tt.cert, tt.key)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("not a valid pair"))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+// generateCA - returns a PEM encoded self signed CA certificate valid from
+// notBefore until notAfter
+func generateCA(t *testing.T, name string, notBefore time.Time, notAfter time.Time) []byte {
+	g := NewWithT(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestParseCABundle(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	validCA := generateCA(t, "valid", now.Add(-time.Hour), now.Add(time.Hour))
+	expiredCA := generateCA(t, "expired", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	futureCA := generateCA(t, "future", now.Add(time.Hour), now.Add(2*time.Hour))
+
+	bundle := append(append(append([]byte{}, expiredCA...), validCA...), futureCA...)
+	certs, err := ParseCABundle(bundle)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(certs).To(HaveLen(3))
+	g.Expect(certs[0].Subject.CommonName).To(Equal("expired"))
+	g.Expect(certs[1].Subject.CommonName).To(Equal("valid"))
+	g.Expect(certs[2].Subject.CommonName).To(Equal("future"))
+
+	valid, expired := FilterExpiredCAs(certs, now)
+	g.Expect(valid).To(HaveLen(2))
+	g.Expect(valid[0].Subject.CommonName).To(Equal("valid"))
+	g.Expect(valid[1].Subject.CommonName).To(Equal("future"))
+	g.Expect(expired).To(HaveLen(1))
+	g.Expect(expired[0].Subject.CommonName).To(Equal("expired"))
+
+	// non certificate blocks are skipped
+	_, key := generateCertKeyPair(t)
+	certs, err = ParseCABundle(append(append([]byte{}, validCA...), key...))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(certs).To(HaveLen(1))
+
+	// empty bundle
+	certs, err = ParseCABundle([]byte{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(certs).To(BeEmpty())
+
+	// invalid data
+	_, err = ParseCABundle([]byte("bundle"))
+	g.Expect(err).To(HaveOccurred())
+	_, err = ParseCABundle(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("cert")}))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestCABundleSecretData(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	internalCA := generateCA(t, "internal", now.Add(-time.Hour), now.Add(time.Hour))
+	systemCA := generateCA(t, "system", now.Add(-time.Hour), now.Add(time.Hour))
+	otherCA := generateCA(t, "other", now.Add(-time.Hour), now.Add(time.Hour))
+
+	// the internal CA is also part of the system bundle and only added once
+	systemBundle := append(append([]byte{}, systemCA...), internalCA...)
+	data, err := CABundleSecretData(internalCA, systemBundle, otherCA)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(data).To(HaveKeyWithValue(InternalCABundleKey, internalCA))
+
+	certs, err := ParseCABundle(data[CABundleKey])
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(certs).To(HaveLen(3))
+	g.Expect(certs[0].Subject.CommonName).To(Equal("internal"))
+	g.Expect(certs[1].Subject.CommonName).To(Equal("system"))
+	g.Expect(certs[2].Subject.CommonName).To(Equal("other"))
+
+	// invalid CAs are rejected
+	_, err = CABundleSecretData([]byte{})
+	g.Expect(err).To(HaveOccurred())
+	_, err = CABundleSecretData(internalCA, []byte("bundle"))
+	g.Expect(err).To(HaveOccurred())
+}
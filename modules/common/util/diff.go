@@ -0,0 +1,94 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strings"
+)
+
+// DiffRenderedData - compares two rendered template data maps, e.g. returned
+// by GetTemplateData, and returns per changed key a diff of the content.
+// Removed lines are prefixed with "-", added lines with "+". Unchanged lines
+// are not part of the diff and unchanged keys are omitted. Keys which only
+// exist in oldData show all lines as removed, keys which only exist in newData
+// show all lines as added.
+// This is meant for debug logging of configuration changes.
+func DiffRenderedData(oldData map[string]string, newData map[string]string) map[string]string {
+	diff := map[string]string{}
+
+	for key, oldValue := range oldData {
+		newValue, ok := newData[key]
+		if ok && newValue == oldValue {
+			continue
+		}
+		diff[key] = diffLines(splitLines(oldValue), splitLines(newValue))
+	}
+
+	for key, newValue := range newData {
+		if _, ok := oldData[key]; ok {
+			continue
+		}
+		diff[key] = diffLines(nil, splitLines(newValue))
+	}
+
+	return diff
+}
+
+// splitLines - splits s into lines, without a trailing empty line
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines - returns the removed and added lines to get from a to b, based
+// on the longest common subsequence of the lines
+func diffLines(a []string, b []string) string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+
+	return sb.String()
+}
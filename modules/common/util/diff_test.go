@@ -0,0 +1,79 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiffRenderedData(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldData map[string]string
+		newData map[string]string
+		want    map[string]string
+	}{
+		{
+			name:    "Unchanged data",
+			oldData: map[string]string{"a.conf": "[DEFAULT]\ndebug=true\n"},
+			newData: map[string]string{"a.conf": "[DEFAULT]\ndebug=true\n"},
+			want:    map[string]string{},
+		},
+		{
+			name:    "Added key",
+			oldData: map[string]string{},
+			newData: map[string]string{"a.conf": "[DEFAULT]\ndebug=true\n"},
+			want:    map[string]string{"a.conf": "+[DEFAULT]\n+debug=true\n"},
+		},
+		{
+			name:    "Removed key",
+			oldData: map[string]string{"a.conf": "[DEFAULT]\ndebug=true\n"},
+			newData: nil,
+			want:    map[string]string{"a.conf": "-[DEFAULT]\n-debug=true\n"},
+		},
+		{
+			name: "Modified key",
+			oldData: map[string]string{
+				"a.conf": "[DEFAULT]\ndebug=true\nverbose=true\n[database]\nconnection=foo\n",
+				"b.conf": "unchanged\n",
+			},
+			newData: map[string]string{
+				"a.conf": "[DEFAULT]\ndebug=false\nverbose=true\n[database]\nconnection=foo\nmax_retries=-1\n",
+				"b.conf": "unchanged\n",
+			},
+			want: map[string]string{
+				"a.conf": "-debug=true\n+debug=false\n+max_retries=-1\n",
+			},
+		},
+		{
+			name:    "Modified key without trailing newline",
+			oldData: map[string]string{"a.conf": "a\nb"},
+			newData: map[string]string{"a.conf": "a\nc"},
+			want:    map[string]string{"a.conf": "-b\n+c\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(DiffRenderedData(tt.oldData, tt.newData)).To(Equal(tt.want))
+		})
+	}
+}
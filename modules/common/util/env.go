@@ -16,7 +16,12 @@ limitations under the License.
 
 package util
 
-import "os"
+import (
+	"os"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
 
 // GetEnvVar - Get the value associated with key from environment variables, but use baseDefault as a value in case the ENV variable is not defined.
 func GetEnvVar(key string, baseDefault string) string {
@@ -25,3 +30,44 @@ func GetEnvVar(key string, baseDefault string) string {
 	}
 	return baseDefault
 }
+
+// BuildContainerEnv - builds the env of a container from literal values,
+// secret key references and configmap key references. The returned list is
+// sorted by the name of the env vars to be stable across reconciles. If the
+// same name is provided by multiple sources, secretRefs take precedence over
+// cmRefs, which take precedence over literals.
+func BuildContainerEnv(
+	literals map[string]string,
+	secretRefs map[string]corev1.SecretKeySelector,
+	cmRefs map[string]corev1.ConfigMapKeySelector,
+) []corev1.EnvVar {
+	envs := map[string]corev1.EnvVar{}
+
+	for name, value := range literals {
+		envs[name] = corev1.EnvVar{Name: name, Value: value}
+	}
+	for name, ref := range cmRefs {
+		ref := ref
+		envs[name] = corev1.EnvVar{
+			Name:      name,
+			ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &ref},
+		}
+	}
+	for name, ref := range secretRefs {
+		ref := ref
+		envs[name] = corev1.EnvVar{
+			Name:      name,
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &ref},
+		}
+	}
+
+	envList := make([]corev1.EnvVar, 0, len(envs))
+	for _, e := range envs {
+		envList = append(envList, e)
+	}
+	sort.Slice(envList, func(i, j int) bool {
+		return envList[i].Name < envList[j].Name
+	})
+
+	return envList
+}
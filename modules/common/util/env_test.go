@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestGetEnvVar(t *testing.T) {
@@ -63,3 +64,78 @@ func TestGetEnvVar(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildContainerEnv(t *testing.T) {
+	secretRef := corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "secret"},
+		Key:                  "password",
+	}
+	cmRef := corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "config"},
+		Key:                  "debug",
+	}
+
+	tests := []struct {
+		name       string
+		literals   map[string]string
+		secretRefs map[string]corev1.SecretKeySelector
+		cmRefs     map[string]corev1.ConfigMapKeySelector
+		want       []corev1.EnvVar
+	}{
+		{
+			name: "No sources",
+			want: []corev1.EnvVar{},
+		},
+		{
+			name:     "Literals are sorted by name",
+			literals: map[string]string{"c": "3", "a": "1", "b": "2"},
+			want: []corev1.EnvVar{
+				{Name: "a", Value: "1"},
+				{Name: "b", Value: "2"},
+				{Name: "c", Value: "3"},
+			},
+		},
+		{
+			name:       "All sources",
+			literals:   map[string]string{"KOLLA_CONFIG_STRATEGY": "COPY_ALWAYS"},
+			secretRefs: map[string]corev1.SecretKeySelector{"PASSWORD": secretRef},
+			cmRefs:     map[string]corev1.ConfigMapKeySelector{"DEBUG": cmRef},
+			want: []corev1.EnvVar{
+				{Name: "DEBUG", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &cmRef}},
+				{Name: "KOLLA_CONFIG_STRATEGY", Value: "COPY_ALWAYS"},
+				{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &secretRef}},
+			},
+		},
+		{
+			name:       "Secret reference wins over configmap reference and literal",
+			literals:   map[string]string{"a": "1"},
+			secretRefs: map[string]corev1.SecretKeySelector{"a": secretRef},
+			cmRefs:     map[string]corev1.ConfigMapKeySelector{"a": cmRef},
+			want: []corev1.EnvVar{
+				{Name: "a", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &secretRef}},
+			},
+		},
+		{
+			name:     "Configmap reference wins over literal",
+			literals: map[string]string{"a": "1"},
+			cmRefs:   map[string]corev1.ConfigMapKeySelector{"a": cmRef},
+			want: []corev1.EnvVar{
+				{Name: "a", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &cmRef}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			envs := BuildContainerEnv(tt.literals, tt.secretRefs, tt.cmRefs)
+			g.Expect(envs).To(Equal(tt.want))
+
+			// the result is stable
+			for i := 0; i < 10; i++ {
+				g.Expect(BuildContainerEnv(tt.literals, tt.secretRefs, tt.cmRefs)).To(Equal(envs))
+			}
+		})
+	}
+}
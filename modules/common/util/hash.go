@@ -35,7 +35,16 @@ type Hash struct {
 	Hash string `json:"hash,omitempty"`
 }
 
+// RestartAnnotationKey - pod template annotation carrying the hash of the
+// configuration of a deployment/statefulset. Changing its value triggers a
+// rollout of the pods.
+const RestartAnnotationKey = "openstack.org/config-hash"
+
 // ObjectHash creates a deep object hash and return it as a safe encoded string
+//
+// The hash is stable for maps, independent of the insertion and iteration
+// order of their entries, as json.Marshal serializes map entries sorted by
+// their keys.
 func ObjectHash(i interface{}) (string, error) {
 	// Convert the hashSource to a byte slice so that it can be hashed
 	hashBytes, err := json.Marshal(i)
@@ -46,6 +55,16 @@ func ObjectHash(i interface{}) (string, error) {
 	return rand.SafeEncodeString(fmt.Sprint(hash)), nil
 }
 
+// HashChanged - calculates the hash of obj and returns true if it differs
+// from the old hash, together with the new hash
+func HashChanged(old string, obj interface{}) (bool, string, error) {
+	hash, err := ObjectHash(obj)
+	if err != nil {
+		return false, "", err
+	}
+	return hash != old, hash, nil
+}
+
 // SetHash - set hashStr of type hashType on hashMap if it does not exist or
 // hashStr is different from current stored value. Returns hashMap and bool
 // which indicates if hashMap changed.
@@ -66,9 +85,15 @@ func SetHash(
 }
 
 // HashOfInputHashes - calculates the overall hash of hashes
+//
+// The hashes are merged into a list of env vars sorted by their name, so the
+// result does not depend on the insertion order of the map. A nil and an empty
+// map result in the same hash, which is the hash of an empty env var list.
 func HashOfInputHashes(
 	hashes map[string]env.Setter,
 ) (string, error) {
+	// always start from a non nil list, so that no hashes is serialized the
+	// same way independent of hashes being nil or empty
 	mergedMapVars := env.MergeEnvs([]corev1.EnvVar{}, hashes)
 	hash, err := ObjectHash(mergedMapVars)
 	if err != nil {
@@ -76,3 +101,17 @@ func HashOfInputHashes(
 	}
 	return hash, nil
 }
+
+// RestartAnnotation - returns the annotation to be set on a pod template to
+// restart the pods when configHash changes
+func RestartAnnotation(configHash string) map[string]string {
+	return map[string]string{
+		RestartAnnotationKey: configHash,
+	}
+}
+
+// NeedsRestart - returns true if the RestartAnnotationKey annotation differs
+// between the old and the new annotations
+func NeedsRestart(old map[string]string, new map[string]string) bool {
+	return old[RestartAnnotationKey] != new[RestartAnnotationKey]
+}
@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	env "github.com/openstack-k8s-operators/lib-common/modules/common/env"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestObjectHash(t *testing.T) {
@@ -50,6 +51,54 @@ func TestObjectHash(t *testing.T) {
 	}
 }
 
+func TestObjectHashMapOrder(t *testing.T) {
+	g := NewWithT(t)
+
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	m1 := map[string]interface{}{}
+	for _, k := range keys {
+		m1[k] = map[string]string{k: k, "x": k}
+	}
+	m2 := map[string]interface{}{}
+	for i := len(keys) - 1; i >= 0; i-- {
+		m2[keys[i]] = map[string]string{"x": keys[i], keys[i]: keys[i]}
+	}
+
+	hash1, err := ObjectHash(m1)
+	g.Expect(err).NotTo(HaveOccurred())
+	hash2, err := ObjectHash(m2)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(hash1).To(Equal(hash2))
+}
+
+func TestHashChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	data := map[string]string{"a": "a"}
+	hash, err := ObjectHash(data)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	changed, newHash, err := HashChanged(hash, data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	g.Expect(newHash).To(Equal(hash))
+
+	changed, newHash, err = HashChanged(hash, map[string]string{"a": "b"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(newHash).NotTo(Equal(hash))
+
+	// an empty old hash is a change
+	changed, newHash, err = HashChanged("", data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(newHash).To(Equal(hash))
+
+	// objects which can not be serialized are an error
+	_, _, err = HashChanged(hash, make(chan int))
+	g.Expect(err).To(HaveOccurred())
+}
+
 func TestSetHash(t *testing.T) {
 
 	tests := []struct {
@@ -139,3 +188,96 @@ func TestHashOfInputHashes(t *testing.T) {
 		})
 	}
 }
+
+func TestHashOfInputHashesDeterministic(t *testing.T) {
+	g := NewWithT(t)
+
+	nilHash, err := HashOfInputHashes(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	emptyHash, err := HashOfInputHashes(map[string]env.Setter{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nilHash).To(Equal(emptyHash))
+	g.Expect(nilHash).NotTo(BeEmpty())
+
+	// the hash of no inputs is stable
+	emptyListHash, err := ObjectHash([]corev1.EnvVar{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nilHash).To(Equal(emptyListHash))
+
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	forward := map[string]env.Setter{}
+	for _, k := range keys {
+		forward[k] = env.SetValue(k + "-hash")
+	}
+	backward := map[string]env.Setter{}
+	for i := len(keys) - 1; i >= 0; i-- {
+		backward[keys[i]] = env.SetValue(keys[i] + "-hash")
+	}
+
+	forwardHash, err := HashOfInputHashes(forward)
+	g.Expect(err).NotTo(HaveOccurred())
+	for i := 0; i < 10; i++ {
+		backwardHash, err := HashOfInputHashes(backward)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(backwardHash).To(Equal(forwardHash))
+	}
+	g.Expect(forwardHash).NotTo(Equal(emptyHash))
+}
+
+func TestRestartAnnotation(t *testing.T) {
+	g := NewWithT(t)
+
+	annotations := RestartAnnotation("abc")
+	g.Expect(annotations).To(HaveLen(1))
+	g.Expect(annotations).To(HaveKeyWithValue(RestartAnnotationKey, "abc"))
+	g.Expect(RestartAnnotation("abc")).To(Equal(annotations))
+}
+
+func TestNeedsRestart(t *testing.T) {
+
+	tests := []struct {
+		name string
+		old  map[string]string
+		new  map[string]string
+		want bool
+	}{
+		{
+			name: "same hash",
+			old:  RestartAnnotation("abc"),
+			new:  RestartAnnotation("abc"),
+			want: false,
+		},
+		{
+			name: "changed hash",
+			old:  RestartAnnotation("abc"),
+			new:  RestartAnnotation("def"),
+			want: true,
+		},
+		{
+			name: "hash added",
+			old:  nil,
+			new:  RestartAnnotation("abc"),
+			want: true,
+		},
+		{
+			name: "hash removed",
+			old:  RestartAnnotation("abc"),
+			new:  map[string]string{"foo": "bar"},
+			want: true,
+		},
+		{
+			name: "other annotations changed",
+			old:  MergeStringMaps(RestartAnnotation("abc"), map[string]string{"foo": "bar"}),
+			new:  MergeStringMaps(RestartAnnotation("abc"), map[string]string{"foo": "baz"}),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(NeedsRestart(tt.old, tt.new)).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// iniEntry - a key of an ini section with the lines of its value, including
+// the lines of triple-quoted and PEM multi-line values. Comments have an empty key.
+type iniEntry struct {
+	key   string
+	lines []string
+}
+
+// iniSection - an ini section with its entries in the order they got parsed.
+// Entries before the first section header belong to a section with an empty
+// name.
+type iniSection struct {
+	name    string
+	entries []iniEntry
+}
+
+// MergeIniSections - merges the ini style config overlay on top of base. Keys
+// of the overlay replace all occurrences of the same key in the same section of
+// base at the position of its first occurrence, keys and sections which only
+// exist in the overlay get appended in the order of the overlay. Comments of
+// base are kept, comments of the overlay get dropped. The result is rendered
+// through removeNewLinesInSections, merging the same overlay twice results in
+// the same config.
+func MergeIniSections(base, overlay string) (string, error) {
+	baseSections, err := parseIniSections(base)
+	if err != nil {
+		return "", fmt.Errorf("error parsing base config: %w", err)
+	}
+	overlaySections, err := parseIniSections(overlay)
+	if err != nil {
+		return "", fmt.Errorf("error parsing overlay config: %w", err)
+	}
+
+	for _, o := range overlaySections {
+		idx := -1
+		for i := range baseSections {
+			if baseSections[i].name == o.name {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			baseSections = append(baseSections, iniSection{name: o.name})
+			idx = len(baseSections) - 1
+		}
+		baseSections[idx].merge(o)
+	}
+
+	var sb strings.Builder
+	for _, s := range baseSections {
+		if s.name != "" {
+			sb.WriteString("[" + s.name + "]\n")
+		}
+		for _, e := range s.entries {
+			for _, l := range e.lines {
+				sb.WriteString(l + "\n")
+			}
+		}
+	}
+
+	return removeNewLinesInSections(sb.String()), nil
+}
+
+// merge - merges the keys of overlay into the section
+func (s *iniSection) merge(overlay iniSection) {
+	// collect the overlay entries per key, keeping repeated keys
+	overlayKeys := []string{}
+	overlayEntries := map[string][]iniEntry{}
+	for _, e := range overlay.entries {
+		if e.key == "" {
+			continue
+		}
+		if _, ok := overlayEntries[e.key]; !ok {
+			overlayKeys = append(overlayKeys, e.key)
+		}
+		overlayEntries[e.key] = append(overlayEntries[e.key], e)
+	}
+
+	merged := []iniEntry{}
+	done := map[string]bool{}
+	for _, e := range s.entries {
+		entries, ok := overlayEntries[e.key]
+		if !ok || e.key == "" {
+			merged = append(merged, e)
+			continue
+		}
+		if !done[e.key] {
+			merged = append(merged, entries...)
+			done[e.key] = true
+		}
+	}
+	for _, k := range overlayKeys {
+		if !done[k] {
+			merged = append(merged, overlayEntries[k]...)
+		}
+	}
+
+	s.entries = merged
+}
+
+// parseIniSections - parses the ini style config in into its sections. Sections
+// with the same name get combined.
+func parseIniSections(in string) ([]iniSection, error) {
+	sections := []iniSection{{}}
+	current := 0
+
+	s := bufio.NewScanner(strings.NewReader(in))
+	multiLine := multiLineValue{}
+	lineNr := 0
+	for s.Scan() {
+		raw := s.Text()
+		lineNr++
+		entries := &sections[current].entries
+
+		if multiLine.isOpen() {
+			// line of a multi-line value, like removeNewLinesInSections
+			// keeps it untouched
+			last := &(*entries)[len(*entries)-1]
+			last.lines = append(last.lines, raw)
+			multiLine.update(raw)
+			continue
+		}
+
+		line := strings.TrimSpace(raw)
+
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			*entries = append(*entries, iniEntry{lines: []string{line}})
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("invalid section header %q in line %d", line, lineNr)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			current = -1
+			for i := range sections {
+				if sections[i].name == name {
+					current = i
+					break
+				}
+			}
+			if current < 0 {
+				sections = append(sections, iniSection{name: name})
+				current = len(sections) - 1
+			}
+		default:
+			key, _, _ := strings.Cut(line, "=")
+			*entries = append(*entries, iniEntry{
+				key:   strings.TrimSpace(key),
+				lines: []string{line},
+			})
+			multiLine.update(line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if multiLine.tripleQuotes {
+		return nil, fmt.Errorf("unterminated triple-quoted value")
+	}
+	if multiLine.pem {
+		return nil, fmt.Errorf("unterminated PEM block")
+	}
+
+	return sections, nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMergeIniSections(t *testing.T) {
+	base := `[DEFAULT]
+# the state path
+state_path = /var/lib/nova
+debug = false
+some_parameter_with_brackets=[test]
+
+
+[oslo_concurrency]
+lock_path = /var/lib/nova/tmp
+
+[pci]
+alias = {"name": "a"}
+alias = {"name": "b"}
+`
+
+	tests := []struct {
+		name    string
+		overlay string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Empty overlay",
+			overlay: "",
+			want: `[DEFAULT]
+# the state path
+state_path = /var/lib/nova
+debug = false
+some_parameter_with_brackets=[test]
+
+[oslo_concurrency]
+lock_path = /var/lib/nova/tmp
+
+[pci]
+alias = {"name": "a"}
+alias = {"name": "b"}
+`,
+		},
+		{
+			name: "Override keys and add keys",
+			overlay: `[DEFAULT]
+# dropped comment
+debug=true
+compute_driver = libvirt.LibvirtDriver
+[pci]
+alias = {"name": "c"}
+`,
+			want: `[DEFAULT]
+# the state path
+state_path = /var/lib/nova
+debug=true
+some_parameter_with_brackets=[test]
+compute_driver = libvirt.LibvirtDriver
+
+[oslo_concurrency]
+lock_path = /var/lib/nova/tmp
+
+[pci]
+alias = {"name": "c"}
+`,
+		},
+		{
+			name: "Add sections in overlay order",
+			overlay: `[zz]
+a = 1
+
+[libvirt]
+images_type = rbd
+cert = -----BEGIN CERTIFICATE-----
+  MIIB
+  -----END CERTIFICATE-----
+`,
+			want: `[DEFAULT]
+# the state path
+state_path = /var/lib/nova
+debug = false
+some_parameter_with_brackets=[test]
+
+[oslo_concurrency]
+lock_path = /var/lib/nova/tmp
+
+[pci]
+alias = {"name": "a"}
+alias = {"name": "b"}
+
+[zz]
+a = 1
+
+[libvirt]
+images_type = rbd
+cert = -----BEGIN CERTIFICATE-----
+  MIIB
+  -----END CERTIFICATE-----
+`,
+		},
+		{
+			name: "Trim indented template bodies",
+			overlay: `[DEFAULT]
+
+    debug=true
+    compute_driver = libvirt.LibvirtDriver
+
+`,
+			want: `[DEFAULT]
+# the state path
+state_path = /var/lib/nova
+debug=true
+some_parameter_with_brackets=[test]
+compute_driver = libvirt.LibvirtDriver
+
+[oslo_concurrency]
+lock_path = /var/lib/nova/tmp
+
+[pci]
+alias = {"name": "a"}
+alias = {"name": "b"}
+`,
+		},
+		{
+			name:    "Invalid section header",
+			overlay: "[DEFAULT\ndebug = true\n",
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated PEM block",
+			overlay: "[DEFAULT]\ncert = -----BEGIN CERTIFICATE-----\n  MIIB\n",
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated triple quotes",
+			overlay: "[DEFAULT]\nfoo = \"\"\"bar\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			merged, err := MergeIniSections(base, tt.overlay)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(merged).To(Equal(tt.want))
+
+			// merging the same overlay again does not change the result
+			again, err := MergeIniSections(merged, tt.overlay)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(again).To(Equal(merged))
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -52,6 +53,18 @@ func WrapErrorForObject(msg string, object client.Object, err error) error {
 		msg, object, key, err)
 }
 
+// WrapErrorForObjectf - like WrapErrorForObject, but wraps both the sentinel
+// error and the cause, so that errors.Is(err, sentinel) as well as errors.Is
+// and errors.As against the cause work on the returned error.
+func WrapErrorForObjectf(sentinel error, msg string, obj runtime.Object, cause error) error {
+	if object, ok := obj.(client.Object); ok {
+		return fmt.Errorf("%w: %s %T %v: %w",
+			sentinel, msg, object, client.ObjectKeyFromObject(object), cause)
+	}
+
+	return fmt.Errorf("%w: %s %T: %w", sentinel, msg, obj, cause)
+}
+
 // LogErrorForObject - Error logging
 func LogErrorForObject(
 	h *helper.Helper,
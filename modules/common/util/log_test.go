@@ -0,0 +1,72 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var errFieldNotFound = errors.New("field not found")
+
+func TestWrapErrorForObjectf(t *testing.T) {
+	cause := k8s_errors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "foo")
+
+	tests := []struct {
+		name    string
+		obj     runtime.Object
+		wantMsg string
+	}{
+		{
+			name: "client object",
+			obj: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "namespace",
+				},
+			},
+			wantMsg: "field not found: error getting *v1.Secret namespace/foo: secrets \"foo\" not found",
+		},
+		{
+			name:    "runtime object",
+			obj:     &corev1.SecretList{},
+			wantMsg: "field not found: error getting *v1.SecretList: secrets \"foo\" not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := WrapErrorForObjectf(errFieldNotFound, "error getting", tt.obj, cause)
+			g.Expect(err).To(MatchError(tt.wantMsg))
+			g.Expect(errors.Is(err, errFieldNotFound)).To(BeTrue())
+			g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+
+			var statusErr *k8s_errors.StatusError
+			g.Expect(errors.As(err, &statusErr)).To(BeTrue())
+			g.Expect(statusErr).To(Equal(cause))
+		})
+	}
+}
@@ -55,6 +55,36 @@ func MergeStringMaps(baseMap map[string]string, extraMaps ...map[string]string)
 	return mergedMap
 }
 
+// MergeStringMapsWithDeletes - merge the overlay map into the base map.
+// In contrast to MergeStringMaps the values of the overlay map overwrite the
+// values of the base map. An overlay entry with the value deleteValue is a
+// deletion marker, the key is removed from the result instead of being set.
+// This allows e.g. to express that a previously set label or annotation was
+// cleared by the user. The input maps are not modified.
+// NOTE: Use a deleteValue which is not a valid value for the map, as an
+// entry with that value can not be added via the overlay.
+func MergeStringMapsWithDeletes(baseMap map[string]string, overlay map[string]string, deleteValue string) map[string]string {
+	mergedMap := make(map[string]string)
+
+	for key, value := range baseMap {
+		mergedMap[key] = value
+	}
+
+	for key, value := range overlay {
+		if value == deleteValue {
+			delete(mergedMap, key)
+			continue
+		}
+		mergedMap[key] = value
+	}
+
+	// Nil the result if the map is empty, see MergeStringMaps
+	if len(mergedMap) == 0 {
+		return nil
+	}
+	return mergedMap
+}
+
 // Pair -
 type Pair struct {
 	Key   string
@@ -75,6 +75,66 @@ func TestMergeStringMaps(t *testing.T) {
 	}
 }
 
+func TestMergeStringMapsWithDeletes(t *testing.T) {
+	const deleteValue = "-"
+
+	tests := []struct {
+		name    string
+		base    map[string]string
+		overlay map[string]string
+		want    map[string]string
+	}{
+		{
+			name:    "Add keys",
+			base:    map[string]string{"a": "a"},
+			overlay: map[string]string{"b": "b"},
+			want:    map[string]string{"a": "a", "b": "b"},
+		},
+		{
+			name:    "Overwrite key, the value in the overlay wins",
+			base:    map[string]string{"a": "a", "b": "b"},
+			overlay: map[string]string{"a": "ax"},
+			want:    map[string]string{"a": "ax", "b": "b"},
+		},
+		{
+			name:    "Delete key",
+			base:    map[string]string{"a": "a", "b": "b"},
+			overlay: map[string]string{"a": deleteValue},
+			want:    map[string]string{"b": "b"},
+		},
+		{
+			name:    "Delete missing key",
+			base:    map[string]string{"a": "a"},
+			overlay: map[string]string{"b": deleteValue},
+			want:    map[string]string{"a": "a"},
+		},
+		{
+			name:    "Delete all keys",
+			base:    map[string]string{"a": "a"},
+			overlay: map[string]string{"a": deleteValue},
+			want:    nil,
+		},
+		{
+			name:    "Nil maps",
+			base:    nil,
+			overlay: nil,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			base := MergeStringMaps(tt.base)
+			mergedMap := MergeStringMapsWithDeletes(tt.base, tt.overlay, deleteValue)
+			g.Expect(mergedMap).To(Equal(tt.want))
+			// the base map is not modified
+			g.Expect(tt.base).To(Equal(base))
+		})
+	}
+}
+
 func TestSortStringMapByValue(t *testing.T) {
 	t.Run("Sort map", func(t *testing.T) {
 		g := NewWithT(t)
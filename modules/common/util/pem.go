@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// ConcatPEM - concatenates the PEM encoded blocks into a single PEM bundle.
+// Each entry of blocks can hold one or more PEM blocks. Line endings get
+// normalized, certificates are validated and duplicate blocks, identified by
+// the fingerprint of their content, are only added once.
+func ConcatPEM(blocks [][]byte) ([]byte, error) {
+	var out bytes.Buffer
+	seen := map[[sha256.Size]byte]bool{}
+
+	for idx, data := range blocks {
+		rest := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+		if len(bytes.TrimSpace(rest)) == 0 {
+			return nil, fmt.Errorf("PEM entry %d is empty", idx)
+		}
+
+		for len(bytes.TrimSpace(rest)) > 0 {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				return nil, fmt.Errorf("PEM entry %d contains invalid data", idx)
+			}
+
+			if block.Type == "CERTIFICATE" {
+				if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+					return nil, fmt.Errorf("PEM entry %d contains an invalid certificate: %w", idx, err)
+				}
+			}
+
+			fingerprint := sha256.Sum256(block.Bytes)
+			if seen[fingerprint] {
+				continue
+			}
+			seen[fingerprint] = true
+
+			if err := pem.Encode(&out, block); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	testCA1 = `-----BEGIN CERTIFICATE-----
+MIIBfzCCASWgAwIBAgIUJCQkrKD/SU45tCqeiwI64r6hy7gwCgYIKoZIzj0EAwIw
+FDESMBAGA1UEAwwJdGVzdC1jYS1hMCAXDTI2MTAxNjEzNDIyOFoYDzIxMjYwOTIy
+MTM0MjI4WjAUMRIwEAYDVQQDDAl0ZXN0LWNhLWEwWTATBgcqhkjOPQIBBggqhkjO
+PQMBBwNCAATfhpSBppQsR8sRYHMuI86lMweLe577ehyJ9oIl2UNsLruSWt1Vt/7+
+OTH5XLHyiyY7JHBoPevQkE8LMFpNassno1MwUTAdBgNVHQ4EFgQU1XOjY51AGXqS
+w9S874533AVHQXQwHwYDVR0jBBgwFoAU1XOjY51AGXqSw9S874533AVHQXQwDwYD
+VR0TAQH/BAUwAwEB/zAKBggqhkjOPQQDAgNIADBFAiAixgKxCR93qnINU5FY9/g5
+fRlN5cow4VmIPHuU4fDXZAIhAPLUMhN7sv44IrAAxEcSzFaO2oDlXlneTcE1Eq/D
+MG09
+-----END CERTIFICATE-----
+`
+	testCA2 = `-----BEGIN CERTIFICATE-----
+MIIBgDCCASWgAwIBAgIUX0YFQpxG4CN/Dw5907YWQ+5CKRAwCgYIKoZIzj0EAwIw
+FDESMBAGA1UEAwwJdGVzdC1jYS1iMCAXDTI2MTAxNjEzNDIyOFoYDzIxMjYwOTIy
+MTM0MjI4WjAUMRIwEAYDVQQDDAl0ZXN0LWNhLWIwWTATBgcqhkjOPQIBBggqhkjO
+PQMBBwNCAAQFTdOrSLTMV8Am88H7BxInPryTjF5THK9dn8t1IPMsFqwVnJZI/X2c
+q9mZ1xUTVUj1A1rJeK69plf0Nn0cQIJ4o1MwUTAdBgNVHQ4EFgQUrz1gtZyt5LoO
+plKZ1BlZvVK6okgwHwYDVR0jBBgwFoAUrz1gtZyt5LoOplKZ1BlZvVK6okgwDwYD
+VR0TAQH/BAUwAwEB/zAKBggqhkjOPQQDAgNJADBGAiEA6QaZGCyM6Ch5QIYhkn1G
++07NIEKabq8KeZbgU/qXWhkCIQDHsd2mZIbnFKtodRPOcRlqlyey7Fr7KclQv56+
+HqPbuw==
+-----END CERTIFICATE-----
+`
+)
+
+func TestConcatPEM(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  [][]byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "No blocks",
+			blocks: [][]byte{},
+			want:   "",
+		},
+		{
+			name:   "Two certificates",
+			blocks: [][]byte{[]byte(testCA1), []byte(testCA2)},
+			want:   testCA1 + testCA2,
+		},
+		{
+			name:   "Bundle with multiple certificates",
+			blocks: [][]byte{[]byte(testCA1 + "\n" + testCA2)},
+			want:   testCA1 + testCA2,
+		},
+		{
+			name:   "Duplicate certificates",
+			blocks: [][]byte{[]byte(testCA1), []byte(testCA2), []byte(testCA1 + testCA2)},
+			want:   testCA1 + testCA2,
+		},
+		{
+			name:   "CRLF line endings and missing trailing newline",
+			blocks: [][]byte{[]byte(strings.TrimSuffix(strings.ReplaceAll(testCA1, "\n", "\r\n"), "\r\n")), []byte(testCA2)},
+			want:   testCA1 + testCA2,
+		},
+		{
+			name:    "Empty block",
+			blocks:  [][]byte{[]byte(testCA1), []byte("")},
+			wantErr: true,
+		},
+		{
+			name:    "Not PEM encoded",
+			blocks:  [][]byte{[]byte("foo")},
+			wantErr: true,
+		},
+		{
+			name:    "Trailing garbage",
+			blocks:  [][]byte{[]byte(testCA1 + "foo")},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid certificate",
+			blocks:  [][]byte{[]byte("-----BEGIN CERTIFICATE-----\nZm9v\n-----END CERTIFICATE-----\n")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			bundle, err := ConcatPEM(tt.blocks)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(bundle)).To(Equal(tt.want))
+		})
+	}
+}
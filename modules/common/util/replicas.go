@@ -0,0 +1,52 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import "fmt"
+
+// Replicas - returns a pointer to the replica count to use, haCount if ha
+// is requested, otherwise single.
+func Replicas(ha bool, single int32, haCount int32) *int32 {
+	replicas := single
+	if ha {
+		replicas = haCount
+	}
+	return &replicas
+}
+
+// ValidateQuorumReplicas - validates that the replica count can form a quorum,
+// which requires an odd number of replicas. A replica count of 0 is allowed
+// to support scaling a service down.
+func ValidateQuorumReplicas(replicas int32) error {
+	if replicas < 0 {
+		return fmt.Errorf("invalid replica count %d, must not be negative", replicas)
+	}
+	if replicas > 0 && replicas%2 == 0 {
+		return fmt.Errorf("invalid replica count %d, quorum based services require an odd number of replicas", replicas)
+	}
+	return nil
+}
+
+// QuorumReplicas - like Replicas, but validates the resulting replica count
+// with ValidateQuorumReplicas.
+func QuorumReplicas(ha bool, single int32, haCount int32) (*int32, error) {
+	replicas := Replicas(ha, single, haCount)
+	if err := ValidateQuorumReplicas(*replicas); err != nil {
+		return nil, err
+	}
+	return replicas, nil
+}
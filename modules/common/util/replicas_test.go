@@ -0,0 +1,127 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestReplicas(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		ha      bool
+		single  int32
+		haCount int32
+		want    int32
+	}{
+		{
+			name:    "Single replica",
+			ha:      false,
+			single:  1,
+			haCount: 3,
+			want:    1,
+		},
+		{
+			name:    "HA replicas",
+			ha:      true,
+			single:  1,
+			haCount: 3,
+			want:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			replicas := Replicas(tt.ha, tt.single, tt.haCount)
+
+			g.Expect(replicas).NotTo(BeNil())
+			g.Expect(*replicas).To(BeIdenticalTo(tt.want))
+		})
+	}
+}
+
+func TestQuorumReplicas(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		ha      bool
+		single  int32
+		haCount int32
+		want    int32
+		error   bool
+	}{
+		{
+			name:    "Single replica",
+			ha:      false,
+			single:  1,
+			haCount: 2,
+			want:    1,
+			error:   false,
+		},
+		{
+			name:    "HA with odd count",
+			ha:      true,
+			single:  1,
+			haCount: 5,
+			want:    5,
+			error:   false,
+		},
+		{
+			name:    "HA with even count",
+			ha:      true,
+			single:  1,
+			haCount: 4,
+			error:   true,
+		},
+		{
+			name:    "Scaled down to zero",
+			ha:      false,
+			single:  0,
+			haCount: 3,
+			want:    0,
+			error:   false,
+		},
+		{
+			name:    "Negative count",
+			ha:      true,
+			single:  1,
+			haCount: -1,
+			error:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			replicas, err := QuorumReplicas(tt.ha, tt.single, tt.haCount)
+
+			if tt.error {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(replicas).To(BeNil())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(*replicas).To(BeIdenticalTo(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ParseQuantity - parses the resource quantity string s, e.g. "500m" or "1Gi"
+func ParseQuantity(s string) (resource.Quantity, error) {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid resource quantity %q: %w", s, err)
+	}
+	return q, nil
+}
+
+// ParseResourceList - converts a map of resource names to quantity strings,
+// e.g. {"cpu": "500m", "memory": "1Gi"}, into a corev1.ResourceList
+func ParseResourceList(resources map[string]string) (corev1.ResourceList, error) {
+	resourceList := corev1.ResourceList{}
+	for name, val := range resources {
+		if name == "" {
+			return nil, fmt.Errorf("invalid empty resource name")
+		}
+		q, err := ParseQuantity(val)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %w", name, err)
+		}
+		resourceList[corev1.ResourceName(name)] = q
+	}
+	return resourceList, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    resource.Quantity
+		wantErr bool
+	}{
+		{
+			name: "cpu millicores",
+			in:   "500m",
+			want: resource.MustParse("500m"),
+		},
+		{
+			name: "memory",
+			in:   "1Gi",
+			want: resource.MustParse("1Gi"),
+		},
+		{
+			name:    "invalid quantity",
+			in:      "1GB",
+			wantErr: true,
+		},
+		{
+			name:    "empty quantity",
+			in:      "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			q, err := ParseQuantity(tt.in)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.in))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(q.Cmp(tt.want)).To(Equal(0))
+		})
+	}
+}
+
+func TestParseResourceList(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      map[string]string
+		want    corev1.ResourceList
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			in:   map[string]string{},
+			want: corev1.ResourceList{},
+		},
+		{
+			name: "cpu and memory",
+			in: map[string]string{
+				"cpu":    "500m",
+				"memory": "1Gi",
+			},
+			want: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("500m"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		},
+		{
+			name: "invalid memory",
+			in: map[string]string{
+				"cpu":    "500m",
+				"memory": "lots",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty resource name",
+			in: map[string]string{
+				"": "1",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rl, err := ParseResourceList(tt.in)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(rl).To(HaveLen(len(tt.want)))
+			for name, q := range tt.want {
+				actual, ok := rl[name]
+				g.Expect(ok).To(BeTrue())
+				g.Expect(actual.Cmp(q)).To(Equal(0))
+			}
+		})
+	}
+}
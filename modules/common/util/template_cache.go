@@ -0,0 +1,103 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// cachedTemplate - content of a template file and the file info it was read with
+type cachedTemplate struct {
+	modTime time.Time
+	size    int64
+	content []byte
+}
+
+var (
+	templateCacheMutex   sync.Mutex
+	templateCacheEnabled bool
+	templateCache        = map[string]cachedTemplate{}
+
+	// readTemplateFile - reads a template file, can be replaced in tests
+	readTemplateFile = os.ReadFile
+)
+
+// EnableTemplateCache - enables or disables the in-memory cache of template
+// files read by ExecuteTemplate and ExecuteTemplateFile. With the cache
+// enabled, a template file is only read again if its modification time or size
+// changed. The cache is disabled by default.
+func EnableTemplateCache(enable bool) {
+	templateCacheMutex.Lock()
+	defer templateCacheMutex.Unlock()
+
+	templateCacheEnabled = enable
+	if !enable {
+		templateCache = map[string]cachedTemplate{}
+	}
+}
+
+// ClearTemplateCache - removes all entries from the template cache
+func ClearTemplateCache() {
+	templateCacheMutex.Lock()
+	defer templateCacheMutex.Unlock()
+
+	templateCache = map[string]cachedTemplate{}
+}
+
+// readTemplate - returns the content of the template file, from the template
+// cache if it is enabled and the file did not change since it was cached. The
+// file is read without holding the cache lock.
+func readTemplate(filename string) ([]byte, error) {
+	templateCacheMutex.Lock()
+	enabled := templateCacheEnabled
+	templateCacheMutex.Unlock()
+
+	if !enabled {
+		return readTemplateFile(filename)
+	}
+
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	templateCacheMutex.Lock()
+	c, ok := templateCache[filename]
+	templateCacheMutex.Unlock()
+	if ok && c.modTime.Equal(fi.ModTime()) && c.size == fi.Size() {
+		return c.content, nil
+	}
+
+	b, err := readTemplateFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	templateCacheMutex.Lock()
+	defer templateCacheMutex.Unlock()
+	if templateCacheEnabled {
+		templateCache[filename] = cachedTemplate{
+			modTime: fi.ModTime(),
+			size:    fi.Size(),
+			content: b,
+		}
+	}
+
+	return b, nil
+}
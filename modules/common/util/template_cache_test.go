@@ -0,0 +1,94 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTemplateCache(t *testing.T) {
+	g := NewWithT(t)
+
+	reads := map[string]int{}
+	lockedReads := 0
+	readTemplateFile = func(name string) ([]byte, error) {
+		reads[filepath.Base(name)]++
+		// files must be read without holding the cache lock
+		if !templateCacheMutex.TryLock() {
+			lockedReads++
+		} else {
+			templateCacheMutex.Unlock()
+		}
+		return os.ReadFile(name)
+	}
+	EnableTemplateCache(true)
+	t.Cleanup(func() {
+		EnableTemplateCache(false)
+		readTemplateFile = os.ReadFile
+	})
+
+	dir := t.TempDir()
+	t.Setenv("OPERATOR_TEMPLATES", dir)
+	changed := filepath.Join(dir, "changed.conf")
+	unchanged := filepath.Join(dir, "unchanged.conf")
+	g.Expect(os.WriteFile(changed, []byte("foo = {{ .Foo }}"), 0644)).To(Succeed())
+	g.Expect(os.WriteFile(unchanged, []byte("bar = {{ .Foo }}"), 0644)).To(Succeed())
+	data := map[string]string{"Foo": "a"}
+
+	for i := 0; i < 2; i++ {
+		s, err := ExecuteTemplateFile("changed.conf", data)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(s).To(Equal("foo = a"))
+		s, err = ExecuteTemplate(unchanged, data)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(s).To(Equal("bar = a"))
+	}
+	g.Expect(reads).To(Equal(map[string]int{"changed.conf": 1, "unchanged.conf": 1}))
+
+	// the modified file gets read again
+	g.Expect(os.WriteFile(changed, []byte("foo = {{ .Foo }}\n"), 0644)).To(Succeed())
+	mtime := time.Now().Add(time.Minute)
+	g.Expect(os.Chtimes(changed, mtime, mtime)).To(Succeed())
+
+	s, err := ExecuteTemplateFile("changed.conf", data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s).To(Equal("foo = a\n"))
+	s, err = ExecuteTemplateFile("unchanged.conf", data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s).To(Equal("bar = a"))
+	g.Expect(reads).To(Equal(map[string]int{"changed.conf": 2, "unchanged.conf": 1}))
+
+	// a cleared cache reads all files again
+	ClearTemplateCache()
+	_, err = ExecuteTemplateFile("unchanged.conf", data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reads).To(Equal(map[string]int{"changed.conf": 2, "unchanged.conf": 2}))
+
+	// with the cache disabled every call reads the file
+	EnableTemplateCache(false)
+	_, err = ExecuteTemplateFile("unchanged.conf", data)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = ExecuteTemplateFile("unchanged.conf", data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reads).To(Equal(map[string]int{"changed.conf": 2, "unchanged.conf": 4}))
+	g.Expect(lockedReads).To(Equal(0))
+}
@@ -19,13 +19,22 @@ package util
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"text/template"
 
+	"gopkg.in/yaml.v3"
+
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -44,6 +53,13 @@ const (
 	TemplateTypeNone TType = "none"
 )
 
+// RecursiveTemplateKeySeparator - replaces the path separator in the keys of
+// templates from subdirectories, as / is not valid in configmap and secret keys
+const RecursiveTemplateKeySeparator = "_"
+
+// versionDirRegex - matches the name of a version subdirectory, e.g. 18.0
+var versionDirRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
 // Template - config map and secret details
 type Template struct {
 	Name               string                 // name of the cm/secret to create based of the Template. Check secret/configmap pkg on details how it is used.
@@ -59,6 +75,10 @@ type Template struct {
 	ConfigOptions      map[string]interface{} // map of parameters as input data to render the templates
 	SkipSetOwner       bool                   // skip setting ownership on the associated configmap
 	Version            string                 // optional version string to separate templates inside the InstanceType/Type directory. E.g. placementapi/config/18.0
+	Immutable          *bool                  // Secrets only, if set to true the secret data can not be changed after creation
+	Recursive          bool                   // include templates from subdirectories of the InstanceType/Type directory, the result is keyed by the path relative to it with / replaced by RecursiveTemplateKeySeparator, e.g. sub_a.conf. Without a Version, version subdirectories get skipped
+	HashLabelKey       string                 // Secrets only, if set the content hash of the secret gets set as label with this key, truncated to the max label value length
+	RawFiles           []string               // files of the InstanceType/Type directory which get added verbatim without template execution, e.g. binary files. Keyed like the rendered templates, configmaps store non UTF-8 content as BinaryData
 }
 
 // GetTemplatesPath get path to templates, either running local or deployed as container
@@ -106,26 +126,73 @@ func GetAllTemplates(path string, kind string, templateType string, version stri
 	}
 
 	// remove any subdiretories from templatesFiles
-	for index := 0; index < len(templatesFiles); index++ {
-		fi, err := os.Stat(templatesFiles[index])
+	templatesFiles, err = FilterRegularFiles(templatesFiles)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+
+	return templatesFiles
+}
+
+// FilterRegularFiles - returns the paths which are regular files, keeping
+// their order. Symlinks are followed, so a symlink to a regular file is kept.
+// Returns an error if one of the paths can not be stat'ed.
+func FilterRegularFiles(paths []string) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		fi, err := os.Stat(p)
 		if err != nil {
-			fmt.Print(err)
-			os.Exit(1)
+			return nil, err
 		}
-		if fi.Mode().IsDir() {
-			templatesFiles = RemoveIndex(templatesFiles, index)
-			index = -1 // restart from the beginning
+		if fi.Mode().IsRegular() {
+			files = append(files, p)
 		}
 	}
 
-	return templatesFiles
+	return files, nil
+}
+
+// GetAllTemplatesRecursive - returns all template files from the
+// path/kind/templateType/<version> directory including all subdirectories.
+// Without a version, the version subdirectories of path/kind/templateType,
+// named like 18.0, get skipped.
+func GetAllTemplatesRecursive(path string, kind string, templateType string, version string) ([]string, error) {
+	templatePath := getTemplateDir(path, kind, templateType, version)
+
+	templatesFiles := []string{}
+	err := filepath.WalkDir(templatePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && version == "" && filepath.Dir(p) == templatePath && versionDirRegex.MatchString(d.Name()) {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			templatesFiles = append(templatesFiles, p)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return templatesFiles, nil
+}
+
+// getTemplateDir - returns the directory holding the templates of the kind/templateType/<version>
+func getTemplateDir(path string, kind string, templateType string, version string) string {
+	if version != "" {
+		return filepath.Join(path, strings.ToLower(kind), templateType, version)
+	}
+	return filepath.Join(path, strings.ToLower(kind), templateType)
 }
 
 // ExecuteTemplate creates a template from the file and
 // execute it with the specified data
 func ExecuteTemplate(templateFile string, data interface{}) (string, error) {
 
-	b, err := os.ReadFile(templateFile)
+	b, err := readTemplate(templateFile)
 	if err != nil {
 
 		return "", err
@@ -140,17 +207,16 @@ func ExecuteTemplate(templateFile string, data interface{}) (string, error) {
 	return renderedTemplate, nil
 }
 
-// template functions
-var tmpl *template.Template
-
 // template function which allows to execute a template from within
-// a template file.
+// a template file. Returns the function bound to the template t.
 // name - name of the template as defined with with `{{define "some-template"}}your template{{end}}
 // data - data to pass into to render the template for all can use `.`
-func execTempl(name string, data interface{}) (string, error) {
-	buf := &bytes.Buffer{}
-	err := tmpl.ExecuteTemplate(buf, name, data)
-	return buf.String(), err
+func execTempl(t *template.Template) func(string, interface{}) (string, error) {
+	return func(name string, data interface{}) (string, error) {
+		buf := &bytes.Buffer{}
+		err := t.ExecuteTemplate(buf, name, data)
+		return buf.String(), err
+	}
 }
 
 // template function to indent the template with n tabs
@@ -167,6 +233,23 @@ func indent(n int, in string) string {
 	return out
 }
 
+// template function to indent the template with n spaces. Unlike indent, the
+// existing indentation of the lines is kept, which allows to embed the output
+// of toYaml into a YAML document.
+func indentSpaces(n int, in string) string {
+	var out string
+	pad := strings.Repeat(" ", n)
+	s := bufio.NewScanner(bytes.NewReader([]byte(in)))
+	for s.Scan() {
+		line := s.Text()
+		if line != "" {
+			line = pad + line
+		}
+		out += line + "\n"
+	}
+	return out
+}
+
 // template function to remove empty lines if there are > n continuous empty lines
 func removeNewLines(n int, in string) string {
 	var out string
@@ -193,24 +276,67 @@ func removeNewLines(n int, in string) string {
 	return out
 }
 
+// multiLineValue - tracks if the lines of an ini style config are within an
+// explicit multi-line value, a triple-quoted (""") value or a PEM block from
+// its -----BEGIN to its -----END line.
+type multiLineValue struct {
+	tripleQuotes bool
+	pem          bool
+}
+
+// isOpen - returns true if the next line is part of a multi-line value
+func (m *multiLineValue) isOpen() bool {
+	return m.tripleQuotes || m.pem
+}
+
+// update - updates the state with the line
+func (m *multiLineValue) update(line string) {
+	if strings.Count(line, `"""`)%2 == 1 {
+		m.tripleQuotes = !m.tripleQuotes
+	}
+
+	begin := strings.LastIndex(line, "-----BEGIN ")
+	end := strings.LastIndex(line, "-----END ")
+	if begin > end {
+		m.pem = true
+	} else if end >= 0 {
+		m.pem = false
+	}
+}
+
 // This function removes extra space and new-lines from conf data.
+// Multi-line values are kept untouched, these are triple-quoted (""") values
+// and PEM blocks, e.g. an embedded certificate. All other lines get trimmed,
+// also indented ones, as templates indent the lines within their actions.
 func removeNewLinesInSections(in string) string {
 	var out string
 	s := bufio.NewScanner(bytes.NewReader([]byte(in)))
 
+	multiLine := multiLineValue{}
 	for s.Scan() {
-		line := strings.TrimSpace(s.Text())
+		raw := s.Text()
 
-		if line != "" {
-			if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-				// new section-header
-				if len(out) > 0 {
-					out += "\n"
-				}
-			}
+		if multiLine.isOpen() {
+			out += raw + "\n"
+			multiLine.update(raw)
+			continue
+		}
+
+		line := strings.TrimSpace(raw)
 
-			out += line + "\n"
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			// new section-header
+			if len(out) > 0 {
+				out += "\n"
+			}
 		}
+
+		out += line + "\n"
+		multiLine.update(line)
 	}
 
 	return out
@@ -226,21 +352,195 @@ func lower(s string) string {
 	return strings.ToLower(s)
 }
 
+// template function to base64 encode a string
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// template function to base64 decode a string
+func b64dec(s string) (string, error) {
+	out, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// template function to return the default value d if the given value is
+// nil or empty, e.g. {{ .Value | default "foo" }}
+func dfault(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+// isEmpty - returns true if v is nil or the zero value of its type
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+// template function to join the elements of a list with sep, e.g.
+// {{ .Filters | join "," }}
+func join(sep string, list interface{}) (string, error) {
+	l, err := toStringList(list)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(l, sep), nil
+}
+
+// template function to render a repeated ini key with one line per element
+// of the list, e.g. {{ repeatKey "enabled_filters" .Filters }} renders
+// enabled_filters = a
+// enabled_filters = b
+func repeatKey(key string, list interface{}) (string, error) {
+	l, err := toStringList(list)
+	if err != nil {
+		return "", err
+	}
+	lines := make([]string, 0, len(l))
+	for _, v := range l {
+		lines = append(lines, fmt.Sprintf("%s = %s", key, v))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// toStringList - returns the elements of the slice or array list as strings
+func toStringList(list interface{}) ([]string, error) {
+	if list == nil {
+		return []string{}, nil
+	}
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", list)
+	}
+	l := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		l = append(l, fmt.Sprint(rv.Index(i).Interface()))
+	}
+	return l, nil
+}
+
+// template function to marshal data to YAML. Map keys are sorted and the
+// trailing newline is removed so the result can be passed to indentSpaces.
+func toYaml(v interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	err := enc.Encode(v)
+	if err != nil {
+		return "", err
+	}
+	err = enc.Close()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// template function to marshal data to JSON, map keys are sorted
+func toJSON(v interface{}) (string, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// template function to quote a value for an oslo.config ini file, e.g.
+// password = {{ quoteIni .Password }}
+// The value gets wrapped in double quotes, which oslo.config strips, and $ is
+// escaped as $$ to prevent variable substitution. Other characters, like
+// quotes, backslashes and %, are kept as they are. Values with new lines can
+// not be represented and result in an error.
+func quoteIni(v interface{}) (string, error) {
+	str := fmt.Sprint(v)
+	if strings.ContainsAny(str, "\r\n") {
+		return "", fmt.Errorf("ini value must not contain new lines")
+	}
+	return `"` + strings.ReplaceAll(str, "$", "$$") + `"`, nil
+}
+
+// template function to quote a value as JSON string, e.g.
+// "password": {{ quoteJSON .Password }}
+func quoteJSON(v interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(fmt.Sprint(v)); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// template function to quote a value in single quotes for a shell script,
+// e.g. PASSWORD={{ squote .Password }}
+// Single quotes in the value get escaped by closing and reopening the quotes,
+// nothing else is expanded.
+func squote(v interface{}) string {
+	return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", `'\''`) + "'"
+}
+
+// template function to quote a value in double quotes for a shell script,
+// e.g. PASSWORD={{ dquote .Password }}
+// The characters which are special within double quotes, \ " $ and `, get
+// escaped with a backslash.
+func dquote(v interface{}) string {
+	return `"` + dquoteReplacer.Replace(fmt.Sprint(v)) + `"`
+}
+
+var dquoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
 // ExecuteTemplateData creates a template from string and
 // execute it with the specified data
 func ExecuteTemplateData(templateData string, data interface{}) (string, error) {
+	return ExecuteTemplateDataWithFuncs(templateData, data, template.FuncMap{})
+}
+
+// ExecuteTemplateDataWithFuncs creates a template from string and execute it
+// with the specified data. The extra functions get merged over the built-in
+// template functions.
+func ExecuteTemplateDataWithFuncs(templateData string, data interface{}, extra template.FuncMap) (string, error) {
 
 	var buff bytes.Buffer
-	var err error
+	// each call uses its own template, execTempl is bound to it
+	tmpl := template.New("tmp").Option("missingkey=error")
 	funcs := template.FuncMap{
 		"add":                      add,
-		"execTempl":                execTempl,
+		"b64dec":                   b64dec,
+		"b64enc":                   b64enc,
+		"default":                  dfault,
+		"dquote":                   dquote,
+		"execTempl":                execTempl(tmpl),
 		"indent":                   indent,
+		"indentSpaces":             indentSpaces,
+		"join":                     join,
 		"lower":                    lower,
+		"quoteIni":                 quoteIni,
+		"quoteJSON":                quoteJSON,
 		"removeNewLines":           removeNewLines,
 		"removeNewLinesInSections": removeNewLinesInSections,
+		"repeatKey":                repeatKey,
+		"squote":                   squote,
+		"toJson":                   toJSON,
+		"toYaml":                   toYaml,
+	}
+	for name, f := range extra {
+		funcs[name] = f
 	}
-	tmpl, err = template.New("tmp").Option("missingkey=error").Funcs(funcs).Parse(templateData)
+
+	_, err := tmpl.Funcs(funcs).Parse(templateData)
 	if err != nil {
 		return "", err
 	}
@@ -269,7 +569,7 @@ func ExecuteTemplateFile(filename string, data interface{}) (string, error) {
 		filepath = path.Join(templates, filename)
 	}
 
-	b, err := os.ReadFile(filepath)
+	b, err := readTemplate(filepath)
 	if err != nil {
 		return "", err
 	}
@@ -278,6 +578,25 @@ func ExecuteTemplateFile(filename string, data interface{}) (string, error) {
 	return ExecuteTemplateData(file, data)
 }
 
+// ValidateConfigOptions - checks that all required keys exist in the
+// ConfigOptions of the template t. Returns an error listing all missing keys,
+// sorted, so that it can be used as actionable message e.g. for the InputReady
+// condition.
+func ValidateConfigOptions(t Template, required []string) error {
+	missing := []string{}
+	for _, key := range required {
+		if _, ok := t.ConfigOptions[key]; !ok && !slices.Contains(missing, key) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+
+	return fmt.Errorf("missing ConfigOptions for template %s: %s", t.Name, strings.Join(missing, ", "))
+}
+
 // GetTemplateData - Renders templates specified via Template struct
 //
 // Check the TType const and Template type for more details on defining the template.
@@ -294,15 +613,51 @@ func GetTemplateData(t Template) (map[string]string, error) {
 
 	if t.Type != TemplateTypeNone {
 		// get all scripts templates which are in ../templesPath/cr.Kind/CMType/<OSPVersion - optional>
-		templatesFiles := GetAllTemplates(templatesPath, t.InstanceType, string(t.Type), string(t.Version))
+		var templatesFiles []string
+		if t.Recursive {
+			templatesFiles, err = GetAllTemplatesRecursive(templatesPath, t.InstanceType, string(t.Type), string(t.Version))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			templatesFiles = GetAllTemplates(templatesPath, t.InstanceType, string(t.Type), string(t.Version))
+		}
+		templateDir := getTemplateDir(templatesPath, t.InstanceType, string(t.Type), string(t.Version))
 
 		// render all template files
 		for _, file := range templatesFiles {
+			name := filepath.Base(file)
+			key := name
+			if t.Recursive {
+				name, err = filepath.Rel(templateDir, file)
+				if err != nil {
+					return data, err
+				}
+				name = filepath.ToSlash(name)
+				// / is not valid in configmap and secret keys
+				key = strings.ReplaceAll(name, "/", RecursiveTemplateKeySeparator)
+				if _, ok := data[key]; ok {
+					return data, fmt.Errorf("duplicate key %s for template %s/%s/%s",
+						key, strings.ToLower(t.InstanceType), t.Type, name)
+				}
+			}
+
+			// raw files are added as is, which keeps non UTF-8 content intact
+			if slices.Contains(t.RawFiles, key) {
+				b, err := readTemplate(file)
+				if err != nil {
+					return data, err
+				}
+				data[key] = string(b)
+				continue
+			}
+
 			renderedData, err := ExecuteTemplate(file, opts)
 			if err != nil {
-				return data, err
+				return data, fmt.Errorf("error rendering %s/%s/%s: %w",
+					strings.ToLower(t.InstanceType), t.Type, name, err)
 			}
-			data[filepath.Base(file)] = renderedData
+			data[key] = renderedData
 		}
 	}
 	// add additional template files from different directory, which
@@ -310,7 +665,7 @@ func GetTemplateData(t Template) (map[string]string, error) {
 	for filename, file := range t.AdditionalTemplate {
 		renderedTemplate, err := ExecuteTemplateFile(file, opts)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("error rendering additional template %s for %s: %w", file, filename, err)
 		}
 		data[filename] = renderedTemplate
 	}
@@ -320,7 +675,7 @@ func GetTemplateData(t Template) (map[string]string, error) {
 		renderedTemplate, err := ExecuteTemplateData(tmplData, opts)
 
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("error rendering string template %s: %w", filename, err)
 		}
 		data[filename] = renderedTemplate
 	}
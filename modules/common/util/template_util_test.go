@@ -1,13 +1,20 @@
 package util
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"text/template"
 
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -37,6 +44,167 @@ func TestLower(t *testing.T) {
 	})
 }
 
+func TestB64(t *testing.T) {
+
+	t.Run("Encode string", func(t *testing.T) {
+		g := NewWithT(t)
+
+		s := b64enc("foobar")
+
+		g.Expect(s).To(BeIdenticalTo("Zm9vYmFy"))
+	})
+
+	t.Run("Decode string", func(t *testing.T) {
+		g := NewWithT(t)
+
+		s, err := b64dec("Zm9vYmFy")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo("foobar"))
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		g := NewWithT(t)
+
+		in := "[DEFAULT]\npassword = s3cr3t!\n"
+		s, err := b64dec(b64enc(in))
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo(in))
+	})
+
+	t.Run("Decode invalid string", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := b64dec("not base64!")
+
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("Encode in template", func(t *testing.T) {
+		g := NewWithT(t)
+
+		s, err := ExecuteTemplateData(`{{ b64enc .Value }} {{ b64dec "Zm9vYmFy" }}`, map[string]string{"Value": "foobar"})
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo("Zm9vYmFy foobar"))
+	})
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name  string
+		given interface{}
+		want  interface{}
+	}{
+		{
+			name:  "nil value",
+			given: nil,
+			want:  "foo",
+		},
+		{
+			name:  "empty string",
+			given: "",
+			want:  "foo",
+		},
+		{
+			name:  "empty map",
+			given: map[string]string{},
+			want:  "foo",
+		},
+		{
+			name:  "nil pointer",
+			given: (*string)(nil),
+			want:  "foo",
+		},
+		{
+			name:  "zero int",
+			given: 0,
+			want:  "foo",
+		},
+		{
+			name:  "string set",
+			given: "bar",
+			want:  "bar",
+		},
+		{
+			name:  "bool set",
+			given: true,
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(dfault("foo", tt.given)).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("Default in template", func(t *testing.T) {
+		g := NewWithT(t)
+
+		data := map[string]interface{}{
+			"Set":   "bar",
+			"Unset": nil,
+		}
+		s, err := ExecuteTemplateData(`{{ .Unset | default "foo" }} {{ default "foo" .Set }}`, data)
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo("foo bar"))
+	})
+}
+
+func TestToYamlToJSON(t *testing.T) {
+	data := map[string]interface{}{
+		"b": "two",
+		"a": map[string]interface{}{
+			"list":  []string{"foo", "bar"},
+			"count": 1,
+		},
+	}
+
+	t.Run("Nested map to YAML", func(t *testing.T) {
+		g := NewWithT(t)
+
+		s, err := toYaml(data)
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo("a:\n  count: 1\n  list:\n    - foo\n    - bar\nb: two"))
+	})
+
+	t.Run("Nested map to JSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		s, err := toJSON(data)
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo(`{"a":{"count":1,"list":["foo","bar"]},"b":"two"}`))
+	})
+
+	t.Run("YAML in template with indent", func(t *testing.T) {
+		g := NewWithT(t)
+
+		s, err := ExecuteTemplateData("config:\n{{ toYaml .Data | indentSpaces 2 }}", map[string]interface{}{"Data": data})
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo("config:\n  a:\n    count: 1\n    list:\n      - foo\n      - bar\n  b: two\n"))
+
+		parsed := map[string]interface{}{}
+		g.Expect(yaml.Unmarshal([]byte(s), &parsed)).To(Succeed())
+		g.Expect(parsed).To(Equal(map[string]interface{}{
+			"config": map[string]interface{}{
+				"a": map[string]interface{}{
+					"list":  []interface{}{"foo", "bar"},
+					"count": 1,
+				},
+				"b": "two",
+			},
+		}))
+	})
+}
+
 func TestIndent(t *testing.T) {
 
 	t.Run("Indent string", func(t *testing.T) {
@@ -54,6 +222,19 @@ bar`
 	})
 }
 
+func TestIndentSpaces(t *testing.T) {
+
+	t.Run("Indent string keeping the existing indentation", func(t *testing.T) {
+		g := NewWithT(t)
+		const in = "foo:\n  bar\n\nbaz"
+		const expct = "  foo:\n    bar\n\n  baz\n"
+
+		s := indentSpaces(2, in)
+
+		g.Expect(s).To(BeIdenticalTo(expct))
+	})
+}
+
 func TestRemoveNewLines(t *testing.T) {
 
 	t.Run("Remove duplicate new lines", func(t *testing.T) {
@@ -126,6 +307,298 @@ remove
 	})
 }
 
+func TestJoinRepeatKey(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		data interface{}
+		want string
+	}{
+		{
+			name: "join",
+			tmpl: `{{ .Filters | join "," }}`,
+			data: map[string]interface{}{"Filters": []string{"a", "b", "c"}},
+			want: "a,b,c",
+		},
+		{
+			name: "join non string list",
+			tmpl: `{{ join ", " .Ports }}`,
+			data: map[string]interface{}{"Ports": []int{80, 443}},
+			want: "80, 443",
+		},
+		{
+			name: "join empty list",
+			tmpl: `{{ .Filters | join "," }}`,
+			data: map[string]interface{}{"Filters": []string{}},
+			want: "",
+		},
+		{
+			name: "repeatKey",
+			tmpl: `{{ repeatKey "enabled_filters" .Filters }}`,
+			data: map[string]interface{}{"Filters": []interface{}{"a", "b"}},
+			want: "enabled_filters = a\nenabled_filters = b",
+		},
+		{
+			name: "repeatKey empty list",
+			tmpl: `{{ repeatKey "enabled_filters" .Filters }}`,
+			data: map[string]interface{}{"Filters": nil},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			s, err := ExecuteTemplateData(tt.tmpl, tt.data)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(s).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("Not a list", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := ExecuteTemplateData(`{{ join "," .Filters }}`, map[string]interface{}{"Filters": "a"})
+		g.Expect(err).To(HaveOccurred())
+		_, err = ExecuteTemplateData(`{{ repeatKey "key" .Filters }}`, map[string]interface{}{"Filters": 1})
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("Survives removeNewLinesInSections", func(t *testing.T) {
+		g := NewWithT(t)
+
+		tmpl := `[DEFAULT]
+debug = true
+
+[filter_scheduler]
+{{ repeatKey "enabled_filters" .Filters }}
+available_filters = {{ .Filters | join "," }}
+
+{{ repeatKey "empty" .Empty }}
+
+[other]
+foo = bar
+`
+		s, err := ExecuteTemplateData(tmpl, map[string]interface{}{
+			"Filters": []string{"AvailabilityZoneFilter", "ComputeFilter"},
+			"Empty":   []string{},
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(removeNewLinesInSections(s)).To(Equal(`[DEFAULT]
+debug = true
+
+[filter_scheduler]
+enabled_filters = AvailabilityZoneFilter
+enabled_filters = ComputeFilter
+available_filters = AvailabilityZoneFilter,ComputeFilter
+
+[other]
+foo = bar
+`))
+	})
+}
+
+func TestQuoteFuncs(t *testing.T) {
+	password := `a'b"c\d%e$f`
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "quoteIni",
+			tmpl: `password = {{ quoteIni .Password }}`,
+			want: `password = "a'b"c\d%e$$f"`,
+		},
+		{
+			name: "quoteJSON",
+			tmpl: `{"password": {{ quoteJSON .Password }}}`,
+			want: `{"password": "a'b\"c\\d%e$f"}`,
+		},
+		{
+			name: "quoteJSON does not escape HTML",
+			tmpl: `{{ quoteJSON "<a&b>" }}`,
+			want: `"<a&b>"`,
+		},
+		{
+			name: "squote",
+			tmpl: `PASSWORD={{ squote .Password }}`,
+			want: `PASSWORD='a'\''b"c\d%e$f'`,
+		},
+		{
+			name: "dquote",
+			tmpl: `PASSWORD={{ dquote .Password }}`,
+			want: `PASSWORD="a'b\"c\\d%e\$f"`,
+		},
+		{
+			name: "dquote backtick",
+			tmpl: "{{ dquote \"`id`\" }}",
+			want: "\"\\`id\\`\"",
+		},
+		{
+			name: "non string value",
+			tmpl: `{{ quoteIni .Count }} {{ quoteJSON .Count }} {{ squote .Count }} {{ dquote .Count }}`,
+			want: `"1" "1" '1' "1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			s, err := ExecuteTemplateData(tt.tmpl, map[string]interface{}{"Password": password, "Count": 1})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(s).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("quoteJSON result is valid JSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		s, err := ExecuteTemplateData(`{{ quoteJSON .Password }}`, map[string]interface{}{"Password": password})
+		g.Expect(err).NotTo(HaveOccurred())
+		var decoded string
+		g.Expect(json.Unmarshal([]byte(s), &decoded)).To(Succeed())
+		g.Expect(decoded).To(Equal(password))
+	})
+
+	t.Run("quoteIni with new line", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := ExecuteTemplateData(`{{ quoteIni .Password }}`, map[string]interface{}{"Password": "a\nb"})
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestValidateConfigOptions(t *testing.T) {
+	tmpl := Template{
+		Name: "test",
+		ConfigOptions: map[string]interface{}{
+			"ServiceUser": "nova",
+			"Empty":       "",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		required []string
+		wantErr  string
+	}{
+		{
+			name:     "Nothing required",
+			required: nil,
+		},
+		{
+			name:     "All present",
+			required: []string{"ServiceUser", "Empty"},
+		},
+		{
+			name:     "Missing keys",
+			required: []string{"ServiceUser", "TransportURL", "DatabaseHost", "Empty", "TransportURL"},
+			wantErr:  "missing ConfigOptions for template test: DatabaseHost, TransportURL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := ValidateConfigOptions(tmpl, tt.required)
+			if tt.wantErr == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(tt.wantErr))
+			}
+		})
+	}
+}
+
+func TestExecuteTemplateDataWithFuncs(t *testing.T) {
+
+	t.Run("Custom function", func(t *testing.T) {
+		g := NewWithT(t)
+
+		extra := template.FuncMap{
+			"shard": func(name string, i int) string {
+				return fmt.Sprintf("%s-shard-%d", name, i)
+			},
+		}
+		s, err := ExecuteTemplateDataWithFuncs(`{{ shard .Name 1 | lower }}`, map[string]string{"Name": "Cell"}, extra)
+
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo("cell-shard-1"))
+	})
+
+	t.Run("Custom function overrides built-in", func(t *testing.T) {
+		g := NewWithT(t)
+
+		extra := template.FuncMap{
+			"lower": strings.ToUpper,
+		}
+		s, err := ExecuteTemplateDataWithFuncs(`{{ lower "foo" }}`, nil, extra)
+
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(s).To(BeIdenticalTo("FOO"))
+	})
+
+	t.Run("Unknown function", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := ExecuteTemplateDataWithFuncs(`{{ shard "foo" }}`, nil, nil)
+
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestExecuteTemplateDataConcurrent(t *testing.T) {
+	g := NewWithT(t)
+
+	const myTmpl = `{{define "inner"}}{{ .Name }}{{end}}{{ execTempl "inner" . }}`
+
+	var wg sync.WaitGroup
+	results := make([]string, 50)
+	errs := make([]error, 50)
+	for i := 0; i < len(results); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = ExecuteTemplateData(myTmpl, map[string]string{"Name": fmt.Sprintf("name-%d", i)})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		g.Expect(errs[i]).NotTo(HaveOccurred())
+		g.Expect(results[i]).To(BeIdenticalTo(fmt.Sprintf("name-%d", i)))
+	}
+}
+
+func TestExecTemplConcurrentTemplates(t *testing.T) {
+	g := NewWithT(t)
+
+	// each goroutine renders its own template with a different definition of
+	// the same named sub-template, execTempl must use the one of its template.
+	var wg sync.WaitGroup
+	results := make([]string, 50)
+	errs := make([]error, 50)
+	for i := 0; i < len(results); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			myTmpl := fmt.Sprintf(`{{define "inner"}}tmpl-%d {{ .Name }}{{end}}{{ execTempl "inner" . | lower }}`, i)
+			results[i], errs[i] = ExecuteTemplateData(myTmpl, map[string]string{"Name": "FOO"})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		g.Expect(errs[i]).NotTo(HaveOccurred())
+		g.Expect(results[i]).To(BeIdenticalTo(fmt.Sprintf("tmpl-%d foo", i)))
+	}
+}
+
 func TestRemoveNewLinesInSections(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -262,6 +735,46 @@ func TestRemoveNewLinesInSections(t *testing.T) {
 			raw:     "[foo]\nkey=[value]\n[bar]",
 			cleaned: "[foo]\nkey=[value]\n\n[bar]\n",
 		},
+		{
+			name:    "Keep indented continuation lines of a multi-line value",
+			raw:     "\n[foo]\n\ncert = -----BEGIN CERTIFICATE-----\n  MIIB\n  [abc]\n  -----END CERTIFICATE-----\n\n\nbar=1\n[goo]",
+			cleaned: "[foo]\ncert = -----BEGIN CERTIFICATE-----\n  MIIB\n  [abc]\n  -----END CERTIFICATE-----\nbar=1\n\n[goo]\n",
+		},
+		{
+			name:    "Trim indented template bodies",
+			raw:     "[DEFAULT]\n\n    foo=bar\n    baz=qux\n\n",
+			cleaned: "[DEFAULT]\nfoo=bar\nbaz=qux\n",
+		},
+		{
+			name:    "Trim indented line outside of a multi-line value",
+			raw:     "[foo]\nmulti = first\n  second",
+			cleaned: "[foo]\nmulti = first\nsecond\n",
+		},
+		{
+			name:    "Single line PEM block",
+			raw:     "[foo]\ncert = -----BEGIN CERTIFICATE-----MIIB-----END CERTIFICATE-----\n  bar=1",
+			cleaned: "[foo]\ncert = -----BEGIN CERTIFICATE-----MIIB-----END CERTIFICATE-----\nbar=1\n",
+		},
+		{
+			name:    "Indented line after empty line is not a continuation",
+			raw:     "[foo]\nkey=1\n\n  bar=2",
+			cleaned: "[foo]\nkey=1\nbar=2\n",
+		},
+		{
+			name:    "Indented line after section header is not a continuation",
+			raw:     "[foo]\n  bar=2",
+			cleaned: "[foo]\nbar=2\n",
+		},
+		{
+			name:    "Keep triple-quoted multi-line value",
+			raw:     "[foo]\n\ncert = \"\"\"-----BEGIN CERTIFICATE-----\nMIIB\n\n[abc]\n-----END CERTIFICATE-----\"\"\"\n\n\n[goo]\nbaz=1",
+			cleaned: "[foo]\ncert = \"\"\"-----BEGIN CERTIFICATE-----\nMIIB\n\n[abc]\n-----END CERTIFICATE-----\"\"\"\n\n[goo]\nbaz=1\n",
+		},
+		{
+			name:    "Single line triple-quoted value",
+			raw:     "[foo]\nkey = \"\"\"value\"\"\"\n\n\nbar=1",
+			cleaned: "[foo]\nkey = \"\"\"value\"\"\"\nbar=1\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -316,6 +829,15 @@ func TestGetAllTemplates(t *testing.T) {
 				filepath.Join(path.Dir(filename), templatePath, "testservice", "config", "foo.conf"),
 			},
 		},
+		{
+			name:     "Get nested TemplateTypeConfig templates with version",
+			kind:     "testnested",
+			tmplType: TemplateTypeConfig,
+			version:  "1.0",
+			want: []string{
+				filepath.Join(path.Dir(filename), templatePath, "testnested", "config", "1.0", "d.conf"),
+			},
+		},
 		{
 			name:     "Get TemplateTypeScripts templates with version",
 			kind:     "testservice",
@@ -343,6 +865,107 @@ func TestGetAllTemplates(t *testing.T) {
 	}
 }
 
+func TestFilterRegularFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	paths := []string{}
+	want := []string{}
+	for i := 0; i < 1000; i++ {
+		p := filepath.Join(dir, fmt.Sprintf("entry-%04d", i))
+		paths = append(paths, p)
+		if i%3 == 0 {
+			// directory with a nested directory and file
+			g.Expect(os.MkdirAll(filepath.Join(p, "nested"), 0o755)).To(Succeed())
+			g.Expect(os.WriteFile(filepath.Join(p, "nested", "file"), []byte{}, 0o644)).To(Succeed())
+			continue
+		}
+		g.Expect(os.WriteFile(p, []byte{}, 0o644)).To(Succeed())
+		want = append(want, p)
+	}
+	link := filepath.Join(dir, "link")
+	g.Expect(os.Symlink(want[0], link)).To(Succeed())
+	paths = append(paths, link)
+	want = append(want, link)
+
+	files, err := FilterRegularFiles(paths)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(Equal(want))
+
+	files, err = FilterRegularFiles(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(BeEmpty())
+
+	_, err = FilterRegularFiles([]string{filepath.Join(dir, "missing")})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGetAllTemplatesRecursive(t *testing.T) {
+
+	// get the package directory
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		panic("No caller information")
+	}
+
+	// set the env var used to specify the template path in the container case
+	os.Setenv("OPERATOR_TEMPLATES", filepath.Join(path.Dir(filename), templatePath))
+
+	tests := []struct {
+		name     string
+		kind     string
+		tmplType TType
+		version  string
+		want     []string
+	}{
+		{
+			name:     "Get nested TemplateTypeConfig templates",
+			kind:     "testnested",
+			tmplType: TemplateTypeConfig,
+			version:  "",
+			want: []string{
+				filepath.Join(path.Dir(filename), templatePath, "testnested", "config", "a.conf"),
+				filepath.Join(path.Dir(filename), templatePath, "testnested", "config", "sub", "b.conf"),
+				filepath.Join(path.Dir(filename), templatePath, "testnested", "config", "sub", "deeper", "c.conf"),
+			},
+		},
+		{
+			name:     "Get TemplateTypeScripts templates with version",
+			kind:     "testservice",
+			tmplType: TemplateTypeScripts,
+			version:  "1.0",
+			want: []string{
+				filepath.Join(path.Dir(filename), templatePath, "testservice", "bin", "1.0", "init.sh"),
+			},
+		},
+		{
+			name:     "Missing template directory",
+			kind:     "missing",
+			tmplType: TemplateTypeConfig,
+			version:  "",
+			want:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p, _ := GetTemplatesPath()
+			g.Expect(p).To(BeADirectory())
+
+			templatesFiles, err := GetAllTemplatesRecursive(p, tt.kind, string(tt.tmplType), tt.version)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(templatesFiles).To(HaveLen(len(tt.want)))
+			g.Expect(templatesFiles).Should(ConsistOf(tt.want))
+			for _, f := range templatesFiles {
+				g.Expect(f).To(BeARegularFile())
+			}
+		})
+	}
+}
+
 func TestGetTemplateData(t *testing.T) {
 
 	// get the package directory
@@ -382,6 +1005,45 @@ func TestGetTemplateData(t *testing.T) {
 			},
 			error: false,
 		},
+		{
+			name: "Render nested TemplateTypeConfig templates recursive",
+			tmpl: Template{
+				Name:         "testnested",
+				Namespace:    "somenamespace",
+				Type:         TemplateTypeConfig,
+				InstanceType: "testnested",
+				Recursive:    true,
+				ConfigOptions: map[string]interface{}{
+					"ServiceUser": "foo",
+					"Count":       1,
+					"Upper":       "BAR",
+				},
+			},
+			want: map[string]string{
+				"a.conf":            "a = 1\n",
+				"sub_b.conf":        "b = foo\n",
+				"sub_deeper_c.conf": "c = bar\n",
+			},
+			error: false,
+		},
+		{
+			name: "Render nested TemplateTypeConfig templates not recursive",
+			tmpl: Template{
+				Name:         "testnested",
+				Namespace:    "somenamespace",
+				Type:         TemplateTypeConfig,
+				InstanceType: "testnested",
+				ConfigOptions: map[string]interface{}{
+					"ServiceUser": "foo",
+					"Count":       1,
+					"Upper":       "BAR",
+				},
+			},
+			want: map[string]string{
+				"a.conf": "a = 1\n",
+			},
+			error: false,
+		},
 		{
 			name: "Render TemplateTypeScripts templates with version",
 			tmpl: Template{
@@ -471,6 +1133,18 @@ function common_func {
 			},
 			error: false,
 		},
+		{
+			name: "Render nested TemplateTypeConfig templates with duplicate keys",
+			tmpl: Template{
+				Name:         "testduplicate",
+				Namespace:    "somenamespace",
+				Type:         TemplateTypeConfig,
+				InstanceType: "testduplicate",
+				Recursive:    true,
+			},
+			want:  map[string]string{},
+			error: true,
+		},
 		{
 			name: "Render TemplateTypeConfig templates with incomplete ConfigOptions",
 			tmpl: Template{
@@ -531,6 +1205,95 @@ function common_func {
 	}
 }
 
+func TestGetTemplateDataRawFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	t.Setenv("OPERATOR_TEMPLATES", dir)
+
+	configDir := filepath.Join(dir, "testraw", "config")
+	g.Expect(os.MkdirAll(configDir, 0o755)).To(Succeed())
+
+	// gzip magic followed by bytes which are invalid UTF-8 and a template
+	// action which must not get executed
+	raw := []byte{0x1f, 0x8b, 0xff, 0xfe, 0x00, 0xc3, '{', '{', ' ', '.', 'F', 'o', 'o', ' ', '}', '}'}
+	g.Expect(os.WriteFile(filepath.Join(configDir, "data.gz"), raw, 0o644)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(configDir, "foo.conf"), []byte("foo = {{ .Foo }}\n"), 0o644)).To(Succeed())
+
+	data, err := GetTemplateData(Template{
+		Name:          "testraw",
+		Namespace:     "somenamespace",
+		Type:          TemplateTypeConfig,
+		InstanceType:  "testraw",
+		ConfigOptions: map[string]interface{}{"Foo": "bar"},
+		RawFiles:      []string{"data.gz"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data).To(HaveLen(2))
+	g.Expect([]byte(data["data.gz"])).To(Equal(raw))
+	g.Expect(data).To(HaveKeyWithValue("foo.conf", "foo = bar\n"))
+}
+
+func TestGetTemplateDataErrorContext(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	t.Setenv("OPERATOR_TEMPLATES", dir)
+
+	configDir := filepath.Join(dir, "testerr", "config")
+	g.Expect(os.MkdirAll(configDir, 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(configDir, "foo.conf"), []byte("foo = {{ .Missing }}\n"), 0o644)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "common.conf"), []byte("{{ if }}\n"), 0o644)).To(Succeed())
+
+	tests := []struct {
+		name    string
+		tmpl    Template
+		wantErr []string
+	}{
+		{
+			name: "Missing key in template file",
+			tmpl: Template{
+				Type:          TemplateTypeConfig,
+				InstanceType:  "TestErr",
+				ConfigOptions: map[string]interface{}{},
+			},
+			wantErr: []string{"error rendering testerr/config/foo.conf: ", "template: tmp:1:9:", "Missing"},
+		},
+		{
+			name: "Parse error in additional template",
+			tmpl: Template{
+				Type:               TemplateTypeNone,
+				ConfigOptions:      map[string]interface{}{},
+				AdditionalTemplate: map[string]string{"my.conf": "common.conf"},
+			},
+			wantErr: []string{"error rendering additional template common.conf for my.conf: ", "template: tmp:1:"},
+		},
+		{
+			name: "Missing key in string template",
+			tmpl: Template{
+				Type:           TemplateTypeNone,
+				ConfigOptions:  map[string]interface{}{},
+				StringTemplate: map[string]string{"my.conf": "{{ .Missing }}"},
+			},
+			wantErr: []string{"error rendering string template my.conf: ", "Missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := GetTemplateData(tt.tmpl)
+			g.Expect(err).To(HaveOccurred())
+			for _, want := range tt.wantErr {
+				g.Expect(err.Error()).To(ContainSubstring(want))
+			}
+			// the template error is kept in the chain
+			g.Expect(errors.Unwrap(err)).NotTo(BeNil())
+		})
+	}
+}
+
 // Run the new line section cleaning twice on an input and ensure that the second cleaning
 // does nothing as the first run cleaned everything
 // This was failing due to empty line handling between sections is unstable.
@@ -549,3 +1312,25 @@ baz=1
 
 	g.Expect(cleaned2).To(Equal(cleaned))
 }
+
+func TestRemoveNewLinesInSectionsWithMultiLineValuesIsStable(t *testing.T) {
+	g := NewWithT(t)
+
+	input := `
+[foo]
+boo=1
+cert = -----BEGIN CERTIFICATE-----
+  MIIB
+
+  -----END CERTIFICATE-----
+
+[goo]
+baz="""one
+
+two"""
+`
+	cleaned := removeNewLinesInSections(input)
+	cleaned2 := removeNewLinesInSections(cleaned)
+
+	g.Expect(cleaned2).To(Equal(cleaned))
+}
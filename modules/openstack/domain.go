@@ -1,9 +1,11 @@
 package openstack
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/go-logr/logr"
+	gophercloud "github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/identity/v3/domains"
 )
 
@@ -13,33 +15,52 @@ type Domain struct {
 	Description string
 }
 
-// CreateDomain - creates a domain with domainName and domainDescription if it does not exist
+// CreateDomain - creates a domain with domainName and domainDescription if it does not exist.
+// If the domain got created concurrently, e.g. by another reconciler, the ID of the existing
+// domain is returned.
 func (o *OpenStack) CreateDomain(log logr.Logger, d Domain) (string, error) {
-	var domainID string
-	allPages, err := domains.List(o.osclient, domains.ListOpts{Name: d.Name}).AllPages()
+	allDomains, err := o.listDomains(d.Name)
 	if err != nil {
-		return domainID, err
-	}
-	allDomains, err := domains.ExtractDomains(allPages)
-	if err != nil {
-		return domainID, err
+		return "", err
 	}
 	if len(allDomains) == 1 {
-		domainID = allDomains[0].ID
-	} else if len(allDomains) == 0 {
-		createOpts := domains.CreateOpts{
-			Name:        d.Name,
-			Description: d.Description,
+		return allDomains[0].ID, nil
+	} else if len(allDomains) > 1 {
+		return "", fmt.Errorf("Multiple domains named \"%s\" found", d.Name)
+	}
+
+	createOpts := domains.CreateOpts{
+		Name:        d.Name,
+		Description: d.Description,
+	}
+	log.Info(fmt.Sprintf("Creating domain %s", d.Name))
+	domain, err := domains.Create(o.osclient, createOpts).Extract()
+	if err != nil {
+		var conflict gophercloud.ErrDefault409
+		if !errors.As(err, &conflict) {
+			return "", err
 		}
-		log.Info(fmt.Sprintf("Creating domain %s", d.Name))
-		domain, err := domains.Create(o.osclient, createOpts).Extract()
+
+		// the domain got created since we listed, so look it up again
+		log.Info(fmt.Sprintf("Domain %s already exists, looking it up", d.Name))
+		allDomains, err = o.listDomains(d.Name)
 		if err != nil {
-			return domainID, err
+			return "", err
+		}
+		if len(allDomains) != 1 {
+			return "", fmt.Errorf("Expected one domain named \"%s\" after conflict, found %d", d.Name, len(allDomains))
 		}
-		domainID = domain.ID
-	} else {
-		return domainID, fmt.Errorf("Multiple domains named \"%s\" found", d.Name)
+		return allDomains[0].ID, nil
 	}
 
-	return domainID, nil
+	return domain.ID, nil
+}
+
+// listDomains - lists the domains with name
+func (o *OpenStack) listDomains(name string) ([]domains.Domain, error) {
+	allPages, err := domains.List(o.osclient, domains.ListOpts{Name: name}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	return domains.ExtractDomains(allPages)
 }
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fakeclient "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+const domainListResponse = `
+{
+    "links": {
+        "next": null
+    },
+    "domains": [
+        %s
+    ]
+}
+`
+
+const domainJSON = `
+{
+    "id": "%s",
+    "name": "heat_stack",
+    "description": "heat stack domain",
+    "enabled": true
+}
+`
+
+func TestCreateDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		// domainID - ID of the existing domain, empty if the domain does not exist
+		domainID string
+		// createStatus - status code returned on domain creation
+		createStatus int
+		wantID       string
+		wantCreates  int
+		wantErr      bool
+	}{
+		{
+			name:        "domain exists",
+			domainID:    "existing",
+			wantID:      "existing",
+			wantCreates: 0,
+		},
+		{
+			name:         "domain does not exist",
+			createStatus: http.StatusCreated,
+			wantID:       "created",
+			wantCreates:  1,
+		},
+		{
+			name:         "domain created concurrently",
+			createStatus: http.StatusConflict,
+			wantID:       "concurrent",
+			wantCreates:  1,
+		},
+		{
+			name:         "domain creation fails",
+			createStatus: http.StatusInternalServerError,
+			wantCreates:  1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.SetupHTTP()
+			defer th.TeardownHTTP()
+
+			domainID := tt.domainID
+			creates := 0
+			th.Mux.HandleFunc("/domains", func(w http.ResponseWriter, r *http.Request) {
+				th.TestHeader(t, r, "X-Auth-Token", fakeclient.TokenID)
+				w.Header().Add("Content-Type", "application/json")
+
+				switch r.Method {
+				case http.MethodGet:
+					th.CheckEquals(t, "heat_stack", r.URL.Query().Get("name"))
+					domain := ""
+					if domainID != "" {
+						domain = fmt.Sprintf(domainJSON, domainID)
+					}
+					w.WriteHeader(http.StatusOK)
+					fmt.Fprintf(w, domainListResponse, domain)
+				case http.MethodPost:
+					th.TestJSONRequest(t, r, `{"domain": {"name": "heat_stack", "description": "heat stack domain"}}`)
+					creates++
+					switch tt.createStatus {
+					case http.StatusCreated:
+						domainID = "created"
+						w.WriteHeader(http.StatusCreated)
+						fmt.Fprintf(w, `{"domain": %s}`, fmt.Sprintf(domainJSON, domainID))
+					case http.StatusConflict:
+						// another reconciler created the domain after our list
+						domainID = "concurrent"
+						w.WriteHeader(http.StatusConflict)
+						fmt.Fprint(w, `{"error": {"code": 409, "message": "Conflict occurred attempting to store domain", "title": "Conflict"}}`)
+					default:
+						w.WriteHeader(tt.createStatus)
+					}
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				}
+			})
+
+			o := newTestOpenStack()
+			id, err := o.CreateDomain(logr.Discard(), Domain{
+				Name:        "heat_stack",
+				Description: "heat stack domain",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateDomain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			th.CheckEquals(t, tt.wantID, id)
+			th.CheckEquals(t, tt.wantCreates, creates)
+		})
+	}
+}
@@ -17,11 +17,18 @@ limitations under the License.
 package openstack
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"net/url"
 
 	"github.com/go-logr/logr"
 	gophercloud "github.com/gophercloud/gophercloud"
 	endpoints "github.com/gophercloud/gophercloud/openstack/identity/v3/endpoints"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 )
 
 // Endpoint -
@@ -30,6 +37,70 @@ type Endpoint struct {
 	ServiceID    string
 	Availability gophercloud.Availability
 	URL          string
+	// TLSEnabled - if set, the endpoint URL is required to use https
+	TLSEnabled bool
+}
+
+// ValidateEndpointURL - validates the endpoint URL. If tlsEnabled is set
+// the URL must use the https scheme.
+func ValidateEndpointURL(endpointURL string, tlsEnabled bool) error {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint URL %s: %w", endpointURL, err)
+	}
+	if tlsEnabled && u.Scheme != "https" {
+		return fmt.Errorf("endpoint URL %s must use https when TLS is enabled", endpointURL)
+	}
+
+	return nil
+}
+
+// ValidateEndpointCertSANs - validates that the hostname of the endpoint URL
+// is covered by the SANs of the PEM encoded certificate
+func ValidateEndpointCertSANs(endpointURL string, certPEM []byte) error {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint URL %s: %w", endpointURL, err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return fmt.Errorf("failed to decode PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	return cert.VerifyHostname(u.Hostname())
+}
+
+// GetTLSConfigFromCa - returns a TLSConfig which trusts the CA bundle of the
+// tls.Ca, e.g. to register internal https endpoints. Returns nil if no
+// CA bundle secret is configured.
+func GetTLSConfigFromCa(
+	ctx context.Context,
+	h *helper.Helper,
+	ca tls.Ca,
+	namespace string,
+) (*TLSConfig, error) {
+	if ca.CaBundleSecretName == "" {
+		return nil, nil
+	}
+
+	caSecret, _, err := secret.GetSecret(ctx, h, ca.CaBundleSecretName, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	caBundle, ok := caSecret.Data[tls.CABundleKey]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in secret %s", tls.CABundleKey, ca.CaBundleSecretName)
+	}
+
+	return &TLSConfig{
+		CACerts: []string{string(caBundle)},
+	}, nil
 }
 
 // CreateEndpoint - create endpoint
@@ -37,6 +108,10 @@ func (o *OpenStack) CreateEndpoint(
 	log logr.Logger,
 	e Endpoint,
 ) (string, error) {
+	err := ValidateEndpointURL(e.URL, e.TLSEnabled)
+	if err != nil {
+		return "", err
+	}
 
 	// validate if endpoint already exist
 	allEndpoints, err := o.GetEndpoints(
@@ -135,6 +210,11 @@ func (o *OpenStack) UpdateEndpoint(
 ) (string, error) {
 	log.Info(fmt.Sprintf("Updating Endpoint %s %s ", e.Name, e.Availability))
 
+	err := ValidateEndpointURL(e.URL, e.TLSEnabled)
+	if err != nil {
+		return "", err
+	}
+
 	// Update the endpoint
 	updateOpts := endpoints.UpdateOpts{
 		Availability: e.Availability,
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateEndpointURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		tlsEnabled bool
+		wantErr    bool
+	}{
+		{
+			name:       "http without TLS",
+			url:        "http://keystone-internal.openstack.svc:5000",
+			tlsEnabled: false,
+			wantErr:    false,
+		},
+		{
+			name:       "https with TLS",
+			url:        "https://keystone-internal.openstack.svc:5000",
+			tlsEnabled: true,
+			wantErr:    false,
+		},
+		{
+			name:       "http with TLS",
+			url:        "http://keystone-internal.openstack.svc:5000",
+			tlsEnabled: true,
+			wantErr:    true,
+		},
+		{
+			name:       "invalid URL",
+			url:        "https://keystone internal:5000",
+			tlsEnabled: true,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEndpointURL(tt.url, tt.tlsEnabled)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEndpointURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateEndpointCertSANs(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{
+			name:    "IP SAN",
+			url:     srv.URL,
+			wantErr: false,
+		},
+		{
+			name:    "DNS SAN",
+			url:     "https://example.com:5000",
+			wantErr: false,
+		},
+		{
+			name:    "hostname not in SANs",
+			url:     "https://keystone-internal.openstack.svc:5000",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEndpointCertSANs(tt.url, certPEM)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEndpointCertSANs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTLSClientConfigCustomCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	tests := []struct {
+		name    string
+		cfg     *TLSConfig
+		wantErr bool
+	}{
+		{
+			name:    "no custom CA",
+			cfg:     nil,
+			wantErr: true,
+		},
+		{
+			name:    "custom CA",
+			cfg:     &TLSConfig{CACerts: []string{string(caPEM)}},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := newTLSClientConfig(tt.cfg)
+			if err != nil {
+				t.Fatalf("newTLSClientConfig() error = %v", err)
+			}
+
+			client := http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
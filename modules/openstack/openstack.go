@@ -19,6 +19,7 @@ package openstack
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -34,6 +35,9 @@ const (
 	defaultRequestTimeout = 10 * time.Second
 )
 
+// ErrAuthenticationFailed - returned if the authentication against the identity service failed
+var ErrAuthenticationFailed = errors.New("authentication failed")
+
 // OpenStack -
 type OpenStack struct {
 	osclient *gophercloud.ServiceClient
@@ -85,27 +89,9 @@ func GetOpenStackProvider(
 	}
 
 	// create tls config
-	tlsConfig := &tls.Config{}
-	if cfg.TLS != nil {
-		if len(cfg.TLS.CACerts) > 0 {
-			caCertPool := x509.NewCertPool()
-			for _, caCert := range cfg.TLS.CACerts {
-				caCertPool.AppendCertsFromPEM([]byte(caCert))
-			}
-			tlsConfig.RootCAs = caCertPool
-		}
-		if cfg.TLS.Insecure {
-			tlsConfig.InsecureSkipVerify = true
-		}
-
-		if cfg.TLS.ClientCert != "" && cfg.TLS.ClientKey != "" {
-			cert, err := tls.LoadX509KeyPair(cfg.TLS.ClientCert, cfg.TLS.ClientKey)
-			if err != nil {
-				return nil, err
-			}
-
-			tlsConfig.Certificates = []tls.Certificate{cert}
-		}
+	tlsConfig, err := newTLSClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
 	}
 
 	transport := &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig}
@@ -122,12 +108,42 @@ func GetOpenStackProvider(
 	// authenticate the client
 	err = openstack.Authenticate(providerClient, opts)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: user %s at %s: %w", ErrAuthenticationFailed, cfg.Username, cfg.AuthURL, err)
 	}
 
 	return providerClient, nil
 }
 
+// newTLSClientConfig - returns the client tls.Config for the TLSConfig settings
+func newTLSClientConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if cfg == nil {
+		return tlsConfig, nil
+	}
+
+	if len(cfg.CACerts) > 0 {
+		caCertPool := x509.NewCertPool()
+		for _, caCert := range cfg.CACerts {
+			caCertPool.AppendCertsFromPEM([]byte(caCert))
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+	if cfg.Insecure {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if cfg.ClientCert != "" && cfg.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
 // GetNovaOpenStackClient creates a new instance of the openstack compute struct from a config struct
 func GetNovaOpenStackClient(
 	log logr.Logger,
@@ -153,7 +169,10 @@ func GetNovaOpenStackClient(
 	return &os, nil
 }
 
-// NewOpenStack creates a new new instance of the openstack identity struct from a config struct
+// NewOpenStack creates a new new instance of the openstack identity struct from a config struct.
+// It authenticates against cfg.AuthURL and returns an error wrapping
+// ErrAuthenticationFailed if the authentication fails. The identity client
+// uses the internal identity endpoint of cfg.Region from the service catalog.
 func NewOpenStack(
 	log logr.Logger,
 	cfg AuthOpts,
@@ -173,7 +192,7 @@ func NewOpenStack(
 
 	identityClient, err := openstack.NewIdentityV3(providerClient, endpointOpts)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error creating identity client for region %q: %w", cfg.Region, err)
 	}
 
 	os := OpenStack{
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	th "github.com/gophercloud/gophercloud/testhelper"
+)
+
+const tokenResponse = `
+{
+    "token": {
+        "expires_at": "2099-01-01T00:00:00.000000Z",
+        "issued_at": "2024-01-01T00:00:00.000000Z",
+        "methods": ["password"],
+        "user": {
+            "id": "admin-id",
+            "name": "admin",
+            "domain": {"id": "default", "name": "Default"}
+        },
+        "catalog": [
+            {
+                "type": "identity",
+                "name": "keystone",
+                "endpoints": [
+                    {
+                        "id": "internal-id",
+                        "interface": "internal",
+                        "region": "regionOne",
+                        "region_id": "regionOne",
+                        "url": "%s"
+                    }
+                ]
+            }
+        ]
+    }
+}
+`
+
+func TestNewOpenStack(t *testing.T) {
+	tests := []struct {
+		name       string
+		authStatus int
+		region     string
+		wantErr    bool
+		wantAuth   bool
+	}{
+		{
+			name:       "authentication succeeds",
+			authStatus: http.StatusCreated,
+			region:     "regionOne",
+		},
+		{
+			name:       "authentication fails",
+			authStatus: http.StatusUnauthorized,
+			region:     "regionOne",
+			wantErr:    true,
+			wantAuth:   true,
+		},
+		{
+			name:       "no identity endpoint in region",
+			authStatus: http.StatusCreated,
+			region:     "regionTwo",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.SetupHTTP()
+			defer th.TeardownHTTP()
+
+			th.Mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+				th.TestMethod(t, r, http.MethodPost)
+				w.Header().Add("Content-Type", "application/json")
+				if tt.authStatus != http.StatusCreated {
+					w.WriteHeader(tt.authStatus)
+					fmt.Fprint(w, `{"error": {"code": 401, "message": "The request you have made requires authentication.", "title": "Unauthorized"}}`)
+					return
+				}
+				w.Header().Add("X-Subject-Token", "token-id")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, tokenResponse, th.Endpoint()+"v3/")
+			})
+
+			o, err := NewOpenStack(logr.Discard(), AuthOpts{
+				AuthURL:    th.Endpoint() + "v3/",
+				Username:   "admin",
+				Password:   "password",
+				TenantName: "admin",
+				DomainName: "Default",
+				Region:     tt.region,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewOpenStack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			th.CheckEquals(t, tt.wantAuth, errors.Is(err, ErrAuthenticationFailed))
+			if tt.wantErr {
+				return
+			}
+
+			th.CheckEquals(t, tt.region, o.GetRegion())
+			th.CheckEquals(t, th.Endpoint()+"v3/", o.GetAuthURL())
+			th.CheckEquals(t, th.Endpoint()+"v3/", o.GetOSClient().Endpoint)
+			th.CheckEquals(t, "token-id", o.GetOSClient().TokenID)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fakeclient "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+// newTestOpenStack - returns an OpenStack using the gophercloud fake
+// service client, which sends the requests to th.Mux
+func newTestOpenStack() *OpenStack {
+	return &OpenStack{
+		osclient: fakeclient.ServiceClient(),
+	}
+}
+
+const projectListResponse = `
+{
+    "links": {
+        "next": null
+    },
+    "projects": [
+        %s
+    ]
+}
+`
+
+const projectJSON = `
+{
+    "id": "%s",
+    "name": "service",
+    "description": "service project",
+    "domain_id": "default",
+    "enabled": true
+}
+`
+
+func TestCreateProject(t *testing.T) {
+	tests := []struct {
+		name       string
+		projectIDs []string
+		wantID     string
+		wantCreate bool
+		wantErr    bool
+	}{
+		{
+			name:       "project exists",
+			projectIDs: []string{"existing"},
+			wantID:     "existing",
+		},
+		{
+			name:       "project does not exist",
+			projectIDs: []string{},
+			wantID:     "created",
+			wantCreate: true,
+		},
+		{
+			name:       "multiple projects with the same name",
+			projectIDs: []string{"first", "second"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.SetupHTTP()
+			defer th.TeardownHTTP()
+
+			created := false
+			th.Mux.HandleFunc("/projects", func(w http.ResponseWriter, r *http.Request) {
+				th.TestHeader(t, r, "X-Auth-Token", fakeclient.TokenID)
+				w.Header().Add("Content-Type", "application/json")
+
+				switch r.Method {
+				case http.MethodGet:
+					th.CheckEquals(t, "service", r.URL.Query().Get("name"))
+					th.CheckEquals(t, "default", r.URL.Query().Get("domain_id"))
+					projects := ""
+					for i, id := range tt.projectIDs {
+						if i > 0 {
+							projects += ","
+						}
+						projects += fmt.Sprintf(projectJSON, id)
+					}
+					w.WriteHeader(http.StatusOK)
+					fmt.Fprintf(w, projectListResponse, projects)
+				case http.MethodPost:
+					th.TestJSONRequest(t, r, `{"project": {"name": "service", "description": "service project", "domain_id": "default"}}`)
+					created = true
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprintf(w, `{"project": %s}`, fmt.Sprintf(projectJSON, "created"))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				}
+			})
+
+			o := newTestOpenStack()
+			projectID, err := o.CreateProject(logr.Discard(), Project{
+				Name:        "service",
+				Description: "service project",
+				DomainID:    "default",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateProject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if projectID != tt.wantID {
+				t.Errorf("CreateProject() = %s, want %s", projectID, tt.wantID)
+			}
+			if created != tt.wantCreate {
+				t.Errorf("CreateProject() created = %v, want %v", created, tt.wantCreate)
+			}
+		})
+	}
+}
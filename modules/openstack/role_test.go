@@ -0,0 +1,189 @@
+/*
+Copyright 2024 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fakeclient "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+const roleListResponse = `
+{
+    "links": {
+        "next": null
+    },
+    "roles": [
+        %s
+    ]
+}
+`
+
+const roleAssignmentListResponse = `
+{
+    "links": {
+        "next": null
+    },
+    "role_assignments": [
+        %s
+    ]
+}
+`
+
+const roleAssignmentJSON = `
+{
+    "role": {
+        "id": "role-id"
+    },
+    "scope": {
+        "project": {
+            "id": "project-id"
+        }
+    },
+    "user": {
+        "id": "user-id"
+    }
+}
+`
+
+// handleListRoles - serves the role list, returning the admin role with
+// roleID if it is not empty
+func handleListRoles(t *testing.T, roleID *string) {
+	th.Mux.HandleFunc("/roles", func(w http.ResponseWriter, r *http.Request) {
+		th.TestHeader(t, r, "X-Auth-Token", fakeclient.TokenID)
+		w.Header().Add("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+			th.CheckEquals(t, "admin", r.URL.Query().Get("name"))
+			role := ""
+			if *roleID != "" {
+				role = fmt.Sprintf(`{"id": "%s", "name": "admin"}`, *roleID)
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, roleListResponse, role)
+		case http.MethodPost:
+			th.TestJSONRequest(t, r, `{"role": {"name": "admin"}}`)
+			*roleID = "created"
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"role": {"id": "%s", "name": "admin"}}`, *roleID)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	})
+}
+
+func TestCreateRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		roleID string
+		wantID string
+	}{
+		{
+			name:   "role exists",
+			roleID: "existing",
+			wantID: "existing",
+		},
+		{
+			name:   "role does not exist",
+			roleID: "",
+			wantID: "created",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.SetupHTTP()
+			defer th.TeardownHTTP()
+
+			roleID := tt.roleID
+			handleListRoles(t, &roleID)
+
+			o := newTestOpenStack()
+			id, err := o.CreateRole(logr.Discard(), "admin")
+			th.AssertNoErr(t, err)
+			th.CheckEquals(t, tt.wantID, id)
+
+			// a second call reuses the role
+			id, err = o.CreateRole(logr.Discard(), "admin")
+			th.AssertNoErr(t, err)
+			th.CheckEquals(t, tt.wantID, id)
+		})
+	}
+}
+
+func TestAssignUserRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		assigned   bool
+		wantAssign bool
+	}{
+		{
+			name:       "role not assigned",
+			assigned:   false,
+			wantAssign: true,
+		},
+		{
+			name:       "role already assigned",
+			assigned:   true,
+			wantAssign: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.SetupHTTP()
+			defer th.TeardownHTTP()
+
+			roleID := "role-id"
+			handleListRoles(t, &roleID)
+
+			th.Mux.HandleFunc("/role_assignments", func(w http.ResponseWriter, r *http.Request) {
+				th.TestMethod(t, r, http.MethodGet)
+				th.TestHeader(t, r, "X-Auth-Token", fakeclient.TokenID)
+				th.CheckEquals(t, "project-id", r.URL.Query().Get("scope.project.id"))
+				th.CheckEquals(t, "user-id", r.URL.Query().Get("user.id"))
+				th.CheckEquals(t, "role-id", r.URL.Query().Get("role.id"))
+
+				assignments := ""
+				if tt.assigned {
+					assignments = roleAssignmentJSON
+				}
+				w.Header().Add("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintf(w, roleAssignmentListResponse, assignments)
+			})
+
+			assigned := false
+			th.Mux.HandleFunc("/projects/project-id/users/user-id/roles/role-id", func(w http.ResponseWriter, r *http.Request) {
+				th.TestMethod(t, r, http.MethodPut)
+				th.TestHeader(t, r, "X-Auth-Token", fakeclient.TokenID)
+				assigned = true
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			o := newTestOpenStack()
+			err := o.AssignUserRole(logr.Discard(), "admin", "user-id", "project-id")
+			th.AssertNoErr(t, err)
+			th.CheckEquals(t, tt.wantAssign, assigned)
+		})
+	}
+}